@@ -1,14 +1,22 @@
 package main
 
 import (
+	"komiku-scraper/internal/analytics"
+	apimiddleware "komiku-scraper/internal/api/middleware"
+	"komiku-scraper/internal/catalog"
+	"komiku-scraper/internal/downloader"
 	"komiku-scraper/internal/handler"
 	"komiku-scraper/internal/middleware"
+	"komiku-scraper/internal/notify"
 	"komiku-scraper/internal/routes"
+	"komiku-scraper/internal/scheduler"
 	"komiku-scraper/internal/service"
+	"komiku-scraper/internal/users"
 	"komiku-scraper/scraper/cache"
 	"komiku-scraper/scraper/komiku"
 	"komiku-scraper/scraper/winbu"
 	"log"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -29,9 +37,56 @@ func main() {
 	komikuService := service.NewKomikuService(komikuClient, c)
 	winbuService := service.NewWinbuService(winbuClient, c)
 
+	// Local catalogue, filled as titles are crawled or opened (nil without SQLite)
+	catalogStore := catalog.NewStore()
+	komikuService.Catalog = catalogStore
+	winbuService.Catalog = catalogStore
+
+	// Unified search fans out to every provider registered here
+	searchService := service.NewSearchService(komikuService, winbuService)
+	relatedService := service.NewRelatedService(catalogStore, komikuService, winbuService)
+	feedService := service.NewFeedService(komikuService, winbuService, catalogStore)
+
+	// User accounts and reading/watching progress (nil without SQLite)
+	userStore := users.NewStore()
+	progressService := service.NewProgressService(userStore, catalogStore, komikuService, winbuService)
+
+	// Followed series are refetched in the background to detect new releases.
+	// Background services yield to API requests at the upstream limiter.
+	releasePoller := service.NewReleasePoller(userStore, komikuService, winbuService)
+	dispatcher := notify.NewDispatcher(userStore)
+	releasePoller.OnRelease = dispatcher.Dispatch
+	go releasePoller.Run()
+
+	// Request log; the cache warmer reads the most requested details from it
+	stats := analytics.NewAnalytics()
+
+	// Refresh cached pages shortly before their TTL runs out, so users are
+	// not the ones waiting on the source sites
+	warmer := service.NewCacheWarmer(komikuService.Background(), winbuService.Background(), stats)
+	jobs := scheduler.New()
+	jobs.Add("home", scheduler.IntervalFromEnv("WARM_HOME_INTERVAL", 12*time.Minute), warmer.Home)
+	jobs.Add("genres", scheduler.IntervalFromEnv("WARM_GENRES_INTERVAL", 5*time.Hour), warmer.Genres)
+	jobs.Add("trending", scheduler.IntervalFromEnv("WARM_TRENDING_INTERVAL", 12*time.Minute), warmer.Trending)
+	jobs.Add("popular-details", scheduler.IntervalFromEnv("WARM_DETAILS_INTERVAL", 50*time.Minute), warmer.PopularDetails)
+	jobs.Add("prune-analytics", scheduler.IntervalFromEnv("ANALYTICS_PRUNE_INTERVAL", 6*time.Hour), stats.Prune)
+	jobs.Start()
+
+	// Downloader (resumes jobs left unfinished by a previous run)
+	dl := downloader.New()
+	go dl.ResumeUnfinished()
+
 	// 4. Initialize Handlers
 	komikuHandler := handler.NewKomikuHandler(komikuService)
 	winbuHandler := handler.NewWinbuHandler(winbuService)
+	downloadHandler := handler.NewDownloadHandler(dl, komikuService)
+	catalogHandler := handler.NewCatalogHandler(catalogStore)
+	searchHandler := handler.NewSearchHandler(searchService)
+	relatedHandler := handler.NewRelatedHandler(relatedService)
+	userHandler := handler.NewUserHandler(userStore, progressService, releasePoller)
+	webhookHandler := handler.NewWebhookHandler(userStore, dispatcher)
+	feedHandler := handler.NewFeedHandler(feedService)
+	jobsHandler := handler.NewJobsHandler(jobs)
 
 	// 4. Initialize Fiber App
 	app := fiber.New()
@@ -43,9 +98,10 @@ func main() {
 		AllowMethods: "GET, POST, PUT, DELETE, OPTIONS",
 	}))
 	app.Use(middleware.RateLimiter()) // Rate limiting: 60 req/min per IP
+	app.Use("/api", apimiddleware.AnalyticsMiddleware(stats))
 
 	// 5. Setup Routes
-	routes.SetupRoutes(app, komikuHandler, winbuHandler)
+	routes.SetupRoutes(app, komikuHandler, winbuHandler, downloadHandler, catalogHandler, searchHandler, relatedHandler, userHandler, webhookHandler, feedHandler, jobsHandler)
 
 	// Serve Frontend (Static Files)
 	app.Static("/", "./dist")
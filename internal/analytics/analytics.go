@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"log"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,21 +19,26 @@ type Analytics struct {
 	db    *sql.DB
 	ctx   context.Context
 	mu    sync.Mutex
+
+	retention time.Duration // events older than this are pruned
 }
 
-// Event represents an analytics event
+// defaultRetention is used when ANALYTICS_RETENTION is unset. It covers the
+// 7-day windows the popular-endpoint queries look at.
+const defaultRetention = 30 * 24 * time.Hour
+
+// Event represents an analytics event. Nothing identifying the client is
+// recorded, only what was requested and how it went.
 type Event struct {
 	Timestamp    time.Time
 	Endpoint     string
 	Method       string
 	StatusCode   int
 	ResponseTime int64 // milliseconds
-	IP           string
-	APIKey       string
-	UserAgent    string
 }
 
-// NewAnalytics creates a new analytics instance
+// NewAnalytics creates a new analytics instance. ANALYTICS_RETENTION (a Go
+// duration, default 30 days) sets how long events are kept.
 func NewAnalytics() *Analytics {
 	// Redis client
 	host := os.Getenv("REDIS_HOST")
@@ -70,9 +76,6 @@ func NewAnalytics() *Analytics {
 		method TEXT NOT NULL,
 		status_code INTEGER NOT NULL,
 		response_time_ms INTEGER NOT NULL,
-		ip TEXT,
-		api_key TEXT,
-		user_agent TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 	CREATE INDEX IF NOT EXISTS idx_timestamp ON events(timestamp);
@@ -84,12 +87,53 @@ func NewAnalytics() *Analytics {
 		return nil
 	}
 
+	// Databases created by older versions have client columns; clear them
+	if _, err := db.Exec(`UPDATE events SET ip = NULL, api_key = NULL, user_agent = NULL
+		WHERE ip IS NOT NULL OR api_key IS NOT NULL OR user_agent IS NOT NULL`); err != nil && !strings.Contains(err.Error(), "no such column") {
+		log.Printf("[Analytics] Failed to clear client columns: %v", err)
+	}
+
+	retention := defaultRetention
+	if v := os.Getenv("ANALYTICS_RETENTION"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			retention = d
+		} else {
+			log.Printf("[Analytics] Invalid ANALYTICS_RETENTION %q, using %s", v, retention)
+		}
+	}
+
+	// Counters are optional: without Redis, events are only logged to SQLite
+	ctx := context.Background()
+	pingCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if err := redisClient.Ping(pingCtx).Err(); err != nil {
+		log.Printf("[Analytics] Redis unavailable (%v), keeping SQLite event log only", err)
+		redisClient.Close()
+		redisClient = nil
+	} else {
+		clearClientCounters(ctx, redisClient)
+	}
+
 	log.Println("[Analytics] Initialized successfully")
 
 	return &Analytics{
-		redis: redisClient,
-		db:    db,
-		ctx:   context.Background(),
+		redis:     redisClient,
+		db:        db,
+		ctx:       ctx,
+		retention: retention,
+	}
+}
+
+// clearClientCounters drops the per-IP and per-API-key counters older
+// versions kept
+func clearClientCounters(ctx context.Context, rdb *redis.Client) {
+	rdb.Del(ctx, "analytics:ips")
+	iter := rdb.Scan(ctx, 0, "analytics:apikey:*", 100).Iterator()
+	for iter.Next(ctx) {
+		rdb.Del(ctx, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		log.Printf("[Analytics] Failed to clear API key counters: %v", err)
 	}
 }
 
@@ -101,13 +145,11 @@ func (a *Analytics) Track(event Event) {
 
 	// Update Redis counters (real-time)
 	go func() {
+		if a.redis == nil {
+			return
+		}
 		a.redis.Incr(a.ctx, "analytics:total_requests")
 		a.redis.Incr(a.ctx, "analytics:endpoint:"+event.Endpoint)
-		a.redis.HIncrBy(a.ctx, "analytics:ips", event.IP, 1)
-
-		if event.APIKey != "" {
-			a.redis.Incr(a.ctx, "analytics:apikey:"+event.APIKey)
-		}
 
 		if event.StatusCode >= 400 {
 			a.redis.Incr(a.ctx, "analytics:errors")
@@ -120,9 +162,9 @@ func (a *Analytics) Track(event Event) {
 		defer a.mu.Unlock()
 
 		_, err := a.db.Exec(`
-			INSERT INTO events (timestamp, endpoint, method, status_code, response_time_ms, ip, api_key, user_agent)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
-		`, event.Timestamp, event.Endpoint, event.Method, event.StatusCode, event.ResponseTime, event.IP, event.APIKey, event.UserAgent)
+			INSERT INTO events (timestamp, endpoint, method, status_code, response_time_ms)
+			VALUES (?, ?, ?, ?, ?)
+		`, event.Timestamp, event.Endpoint, event.Method, event.StatusCode, event.ResponseTime)
 
 		if err != nil {
 			log.Printf("[Analytics] Failed to insert event: %v", err)
@@ -136,8 +178,13 @@ func (a *Analytics) GetSummary() map[string]interface{} {
 		return map[string]interface{}{"error": "analytics not initialized"}
 	}
 
-	total, _ := a.redis.Get(a.ctx, "analytics:total_requests").Int64()
-	errors, _ := a.redis.Get(a.ctx, "analytics:errors").Int64()
+	var total, errors int64
+	if a.redis != nil {
+		total, _ = a.redis.Get(a.ctx, "analytics:total_requests").Int64()
+		errors, _ = a.redis.Get(a.ctx, "analytics:errors").Int64()
+	} else {
+		a.db.QueryRow(`SELECT COUNT(*), COALESCE(SUM(status_code >= 400), 0) FROM events`).Scan(&total, &errors)
+	}
 
 	return map[string]interface{}{
 		"total_requests": total,
@@ -181,6 +228,67 @@ func (a *Analytics) GetPopularEndpoints(limit int) ([]map[string]interface{}, er
 	return results, nil
 }
 
+// PathHits is how often one request path was answered successfully
+type PathHits struct {
+	Path string
+	Hits int64
+}
+
+// TopPaths returns the most requested paths starting with prefix since the
+// given time, counting only successful responses
+func (a *Analytics) TopPaths(prefix string, since time.Time, limit int) ([]PathHits, error) {
+	if a == nil {
+		return nil, nil
+	}
+
+	rows, err := a.db.Query(`
+		SELECT endpoint, COUNT(*) as hits
+		FROM events
+		WHERE endpoint LIKE ? ESCAPE '\' AND status_code < 400 AND timestamp > ?
+		GROUP BY endpoint
+		ORDER BY hits DESC
+		LIMIT ?
+	`, likePrefix(prefix), since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []PathHits
+	for rows.Next() {
+		var p PathHits
+		if err := rows.Scan(&p.Path, &p.Hits); err != nil {
+			return nil, err
+		}
+		results = append(results, p)
+	}
+	return results, rows.Err()
+}
+
+// likePrefix escapes LIKE wildcards in prefix and matches anything after it
+func likePrefix(prefix string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return r.Replace(prefix) + "%"
+}
+
+// Prune deletes events older than the retention period
+func (a *Analytics) Prune() error {
+	if a == nil {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	res, err := a.db.Exec(`DELETE FROM events WHERE timestamp < ?`, time.Now().Add(-a.retention))
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		log.Printf("[Analytics] Pruned %d events older than %s", n, a.retention)
+	}
+	return nil
+}
+
 // Close closes database connections
 func (a *Analytics) Close() {
 	if a != nil {
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireAdminKey guards operator-only routes: the X-API-Key header must
+// equal ADMIN_API_KEY. Without ADMIN_API_KEY the guarded routes are disabled.
+func RequireAdminKey() fiber.Handler {
+	adminKey := os.Getenv("ADMIN_API_KEY")
+	return func(c *fiber.Ctx) error {
+		if adminKey == "" {
+			return c.Status(503).JSON(fiber.Map{"error": "Admin routes are disabled (ADMIN_API_KEY not set)"})
+		}
+		key := c.Get("X-API-Key")
+		if subtle.ConstantTimeCompare([]byte(key), []byte(adminKey)) != 1 {
+			return c.Status(401).JSON(fiber.Map{"error": "Valid admin API key required"})
+		}
+		return c.Next()
+	}
+}
+
+// RequireAdminKeyIf applies RequireAdminKey to the requests cond matches and
+// lets the others through, for routes where only some options are operator-only
+func RequireAdminKeyIf(cond func(*fiber.Ctx) bool) fiber.Handler {
+	requireAdmin := RequireAdminKey()
+	return func(c *fiber.Ctx) error {
+		if cond(c) {
+			return requireAdmin(c)
+		}
+		return c.Next()
+	}
+}
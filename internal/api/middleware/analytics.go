@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"komiku-scraper/internal/analytics"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -15,17 +16,16 @@ func AnalyticsMiddleware(analyticsService *analytics.Analytics) fiber.Handler {
 		// Process request
 		err := c.Next()
 
-		// Track analytics
+		// Track analytics. Fiber reuses request buffers once the handler
+		// returns, so strings are copied before Track stores them in the
+		// background.
 		if analyticsService != nil {
 			event := analytics.Event{
 				Timestamp:    start,
-				Endpoint:     c.Path(),
-				Method:       c.Method(),
+				Endpoint:     strings.Clone(c.Path()),
+				Method:       strings.Clone(c.Method()),
 				StatusCode:   c.Response().StatusCode(),
 				ResponseTime: time.Since(start).Milliseconds(),
-				IP:           c.IP(),
-				APIKey:       c.Get("X-API-Key"),
-				UserAgent:    c.Get("User-Agent"),
 			}
 
 			analyticsService.Track(event)
@@ -0,0 +1,280 @@
+package catalog
+
+import (
+	"database/sql"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Providers a catalogue entry can come from
+const (
+	ProviderKomiku = "komiku"
+	ProviderWinbu  = "winbu"
+)
+
+// Entry is one manga or anime title known to the catalogue
+type Entry struct {
+	Provider  string    `json:"provider"`
+	Slug      string    `json:"slug"`
+	Title     string    `json:"title"`
+	AltTitles []string  `json:"alt_titles"`
+	Genres    []string  `json:"genres"`
+	Authors   []string  `json:"authors"`
+	Status    string    `json:"status"` // "ongoing", "completed", "upcoming" or ""
+	Type      string    `json:"type"`   // "Manga", "Manhwa", "TV", "Movie", ...
+	Thumb     string    `json:"thumb"`
+	Endpoint  string    `json:"endpoint"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Store keeps the catalogue in SQLite. Titles, alternative titles and authors
+// are indexed with FTS5 when the sqlite3 driver was built with it (the
+// sqlite_fts5 build tag); otherwise search falls back to LIKE matching.
+type Store struct {
+	db  *sql.DB
+	fts bool
+}
+
+// NewStore opens (or creates) the catalogue database
+func NewStore() *Store {
+	dbPath := os.Getenv("CATALOG_DB_PATH")
+	if dbPath == "" {
+		dbPath = "./catalog.db"
+	}
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		log.Printf("[Catalog] Failed to open SQLite: %v", err)
+		return nil
+	}
+
+	// List columns are stored newline separated; genre_keys holds the genre
+	// slugs as "|action|slice-of-life|" for filtering and search_text the
+	// normalised titles and authors (see searchText)
+	schema := `
+	CREATE TABLE IF NOT EXISTS entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		provider TEXT NOT NULL,
+		slug TEXT NOT NULL,
+		title TEXT NOT NULL,
+		alt_titles TEXT NOT NULL DEFAULT '',
+		genres TEXT NOT NULL DEFAULT '',
+		genre_keys TEXT NOT NULL DEFAULT '',
+		authors TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT '',
+		type TEXT NOT NULL DEFAULT '',
+		thumb TEXT NOT NULL DEFAULT '',
+		endpoint TEXT NOT NULL DEFAULT '',
+		search_text TEXT NOT NULL DEFAULT '',
+		updated_at DATETIME NOT NULL,
+		UNIQUE(provider, slug)
+	);
+	CREATE INDEX IF NOT EXISTS idx_entries_title ON entries(title COLLATE NOCASE);
+	CREATE INDEX IF NOT EXISTS idx_entries_updated ON entries(updated_at);
+	CREATE TABLE IF NOT EXISTS links (
+		provider TEXT NOT NULL,
+		slug TEXT NOT NULL,
+		other_provider TEXT NOT NULL,
+		other_slug TEXT NOT NULL,
+		score REAL NOT NULL,
+		source TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		PRIMARY KEY (provider, slug, other_provider, other_slug)
+	);
+	`
+
+	if _, err := db.Exec(schema); err != nil {
+		log.Printf("[Catalog] Failed to create schema: %v", err)
+		return nil
+	}
+
+	s := &Store{db: db}
+	_, err = db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS entries_fts USING fts5(
+		title, alt_titles, authors, tokenize = 'unicode61 remove_diacritics 2'
+	)`)
+	if err == nil {
+		s.fts = true
+	} else {
+		log.Printf("[Catalog] FTS5 unavailable, using LIKE search: %v", err)
+	}
+
+	// SQLite only allows one writer; serialize access from request goroutines
+	db.SetMaxOpenConns(1)
+
+	return s
+}
+
+// FTS reports whether searches use the FTS5 index
+func (s *Store) FTS() bool {
+	return s != nil && s.fts
+}
+
+// Upsert adds or refreshes entries. Empty fields never overwrite known
+// values, so a bare archive listing does not wipe the genres and authors a
+// detail fetch stored earlier.
+func (s *Store) Upsert(entries ...Entry) error {
+	if s == nil || len(entries) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	for _, e := range entries {
+		if e.Provider == "" || e.Slug == "" || e.Title == "" {
+			continue
+		}
+		_, err := tx.Exec(`INSERT INTO entries
+			(provider, slug, title, alt_titles, genres, genre_keys, authors, status, type, thumb, endpoint, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(provider, slug) DO UPDATE SET
+				title = excluded.title,
+				alt_titles = COALESCE(NULLIF(excluded.alt_titles, ''), alt_titles),
+				genres = COALESCE(NULLIF(excluded.genres, ''), genres),
+				genre_keys = COALESCE(NULLIF(excluded.genre_keys, ''), genre_keys),
+				authors = COALESCE(NULLIF(excluded.authors, ''), authors),
+				status = COALESCE(NULLIF(excluded.status, ''), status),
+				type = COALESCE(NULLIF(excluded.type, ''), type),
+				thumb = COALESCE(NULLIF(excluded.thumb, ''), thumb),
+				endpoint = COALESCE(NULLIF(excluded.endpoint, ''), endpoint),
+				updated_at = excluded.updated_at`,
+			e.Provider, e.Slug, strings.TrimSpace(e.Title), joinList(e.AltTitles), joinList(e.Genres), genreKeys(e.Genres),
+			joinList(e.Authors), e.Status, e.Type, e.Thumb, e.Endpoint, now)
+		if err != nil {
+			return err
+		}
+
+		// Re-index from the merged row
+		var (
+			id                        int64
+			title, altTitles, authors string
+		)
+		err = tx.QueryRow(`SELECT id, title, alt_titles, authors FROM entries WHERE provider = ? AND slug = ?`,
+			e.Provider, e.Slug).Scan(&id, &title, &altTitles, &authors)
+		if err != nil {
+			return err
+		}
+		text := searchText(title, splitList(altTitles), splitList(authors))
+		if _, err := tx.Exec(`UPDATE entries SET search_text = ? WHERE id = ?`, text, id); err != nil {
+			return err
+		}
+		if s.fts {
+			if _, err := tx.Exec(`DELETE FROM entries_fts WHERE rowid = ?`, id); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`INSERT INTO entries_fts (rowid, title, alt_titles, authors) VALUES (?, ?, ?, ?)`,
+				id, title, altTitles, authors); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit()
+}
+
+// Get returns one entry, or nil when it is not catalogued
+func (s *Store) Get(provider, slug string) (*Entry, error) {
+	if s == nil {
+		return nil, nil
+	}
+	rows, err := s.db.Query(`SELECT `+entryColumns+` FROM entries e WHERE provider = ? AND slug = ?`, provider, slug)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := scanEntries(rows)
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+// Count returns the number of catalogued titles per provider
+func (s *Store) Count() (map[string]int, error) {
+	counts := make(map[string]int)
+	if s == nil {
+		return counts, nil
+	}
+	rows, err := s.db.Query(`SELECT provider, COUNT(*) FROM entries GROUP BY provider`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var provider string
+		var n int
+		if err := rows.Scan(&provider, &n); err != nil {
+			return nil, err
+		}
+		counts[provider] = n
+	}
+	return counts, rows.Err()
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+const entryColumns = `e.provider, e.slug, e.title, e.alt_titles, e.genres, e.authors, e.status, e.type, e.thumb, e.endpoint, e.updated_at`
+
+func scanEntries(rows *sql.Rows) ([]Entry, error) {
+	defer rows.Close()
+	entries := []Entry{}
+	for rows.Next() {
+		var (
+			e                          Entry
+			altTitles, genres, authors string
+		)
+		err := rows.Scan(&e.Provider, &e.Slug, &e.Title, &altTitles, &genres, &authors,
+			&e.Status, &e.Type, &e.Thumb, &e.Endpoint, &e.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		e.AltTitles = splitList(altTitles)
+		e.Genres = splitList(genres)
+		e.Authors = splitList(authors)
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func joinList(items []string) string {
+	var kept []string
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			kept = append(kept, item)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return []string{}
+	}
+	return strings.Split(s, "\n")
+}
+
+// genreKeys renders genres as "|action|slice-of-life|"
+func genreKeys(genres []string) string {
+	var keys []string
+	for _, g := range genres {
+		if key := GenreKey(g); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return ""
+	}
+	return "|" + strings.Join(keys, "|") + "|"
+}
@@ -0,0 +1,128 @@
+package catalog
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"komiku-scraper/scraper/common"
+	"komiku-scraper/scraper/komiku"
+	"komiku-scraper/scraper/winbu"
+)
+
+// SlugFromEndpoint returns the last path segment of a provider URL or path:
+// "https://komiku.org/manga/one-piece/" gives "one-piece"
+func SlugFromEndpoint(endpoint string) string {
+	u, err := url.Parse(strings.TrimSpace(endpoint))
+	if err != nil {
+		return ""
+	}
+	slug := path.Base(strings.TrimSuffix(u.Path, "/"))
+	if slug == "." || slug == "/" {
+		return ""
+	}
+	return slug
+}
+
+// AbsoluteURL makes a scraped href absolute on its provider's site; hrefs
+// are sometimes full URLs and sometimes paths
+func AbsoluteURL(provider, endpoint string) string {
+	if endpoint == "" || strings.HasPrefix(endpoint, "http") {
+		return endpoint
+	}
+	base := common.KomikuBaseURL
+	if provider == ProviderWinbu {
+		base = common.WinbuBaseURL
+	}
+	return base + "/" + strings.TrimPrefix(endpoint, "/")
+}
+
+// FromMangaDetail builds the entry for a komiku detail page
+func FromMangaDetail(endpoint string, d *komiku.MangaDetail) Entry {
+	e := Entry{
+		Provider: ProviderKomiku,
+		Slug:     SlugFromEndpoint(endpoint),
+		Title:    d.Title,
+		Genres:   d.Genres,
+		Authors:  d.Authors,
+		Status:   normalizeStatus(d.Status),
+		Type:     d.Type,
+		Thumb:    d.Thumb,
+		Endpoint: endpoint,
+	}
+	if d.AltTitle != "" {
+		e.AltTitles = []string{d.AltTitle}
+	}
+	return e
+}
+
+// FromManga builds a bare entry from a komiku list card
+func FromManga(m komiku.Manga) Entry {
+	e := Entry{
+		Provider: ProviderKomiku,
+		Slug:     SlugFromEndpoint(m.Endpoint),
+		Title:    m.Title,
+		Type:     m.Type,
+		Thumb:    m.Thumb,
+		Endpoint: m.Endpoint,
+	}
+	if m.Genre != "" {
+		e.Genres = []string{m.Genre}
+	}
+	return e
+}
+
+// FromAnimeDetail builds the entry for a winbu anime or film page
+func FromAnimeDetail(endpoint string, d *winbu.AnimeDetail) Entry {
+	e := Entry{
+		Provider:  ProviderWinbu,
+		Slug:      SlugFromEndpoint(endpoint),
+		Title:     d.Title,
+		AltTitles: d.AltTitles,
+		Genres:    d.Genres,
+		Status:    d.Status,
+		Type:      d.Type,
+		Thumb:     d.Thumb,
+		Endpoint:  endpoint,
+	}
+	if d.Studio != "" {
+		e.Authors = []string{d.Studio}
+	}
+	return e
+}
+
+// FromAnime builds a bare entry from a winbu archive or list card
+func FromAnime(a winbu.Anime) Entry {
+	return Entry{
+		Provider: ProviderWinbu,
+		Slug:     SlugFromEndpoint(a.Endpoint),
+		Title:    a.Title,
+		Status:   normalizeStatus(a.Status),
+		Type:     a.Type,
+		Thumb:    a.Thumb,
+		Endpoint: a.Endpoint,
+	}
+}
+
+// Manga turns a komiku entry back into a list card
+func (e Entry) Manga() komiku.Manga {
+	m := komiku.Manga{Title: e.Title, Endpoint: e.Endpoint, Thumb: e.Thumb, Type: e.Type}
+	if len(e.Genres) > 0 {
+		m.Genre = e.Genres[0]
+	}
+	return m
+}
+
+// Anime turns a winbu entry back into a list card
+func (e Entry) Anime() winbu.Anime {
+	return winbu.Anime{Title: e.Title, Endpoint: e.Endpoint, Thumb: e.Thumb, Type: e.Type, Status: e.Status}
+}
+
+// normalizeStatus maps komiku's "Ongoing"/"End"/"Tamat" and winbu card labels
+// onto winbu's status values
+func normalizeStatus(status string) string {
+	if strings.EqualFold(strings.TrimSpace(status), "end") {
+		return winbu.StatusCompleted
+	}
+	return winbu.ParseStatus(status)
+}
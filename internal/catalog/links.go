@@ -0,0 +1,77 @@
+package catalog
+
+import "time"
+
+// Link sources
+const (
+	LinkAuto     = "auto"     // matched with a score above the confirm threshold
+	LinkManual   = "manual"   // confirmed through the API
+	LinkRejected = "rejected" // marked as not the same story through the API
+)
+
+// Link says that two catalogue entries on different providers are the same
+// story, e.g. a komiku manga and its winbu anime adaptation
+type Link struct {
+	Provider      string    `json:"provider"`
+	Slug          string    `json:"slug"`
+	OtherProvider string    `json:"other_provider"`
+	OtherSlug     string    `json:"other_slug"`
+	Score         float64   `json:"score"`
+	Source        string    `json:"source"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// SaveLink stores l in both directions. Manual confirmations and rejections
+// replace whatever was stored; an automatic match only replaces another
+// automatic one, so it never overrides a decision made through the API.
+func (s *Store) SaveLink(l Link) error {
+	if s == nil {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	pairs := [][4]string{
+		{l.Provider, l.Slug, l.OtherProvider, l.OtherSlug},
+		{l.OtherProvider, l.OtherSlug, l.Provider, l.Slug},
+	}
+	for _, p := range pairs {
+		_, err := tx.Exec(`INSERT INTO links (provider, slug, other_provider, other_slug, score, source, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(provider, slug, other_provider, other_slug) DO UPDATE SET
+				score = excluded.score, source = excluded.source
+			WHERE links.source = ? OR excluded.source != ?`,
+			p[0], p[1], p[2], p[3], l.Score, l.Source, now, LinkAuto, LinkAuto)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Links returns the stored links of one entry, best score first, including
+// rejected ones so matching can skip them
+func (s *Store) Links(provider, slug string) ([]Link, error) {
+	links := []Link{}
+	if s == nil {
+		return links, nil
+	}
+	rows, err := s.db.Query(`SELECT provider, slug, other_provider, other_slug, score, source, created_at
+		FROM links WHERE provider = ? AND slug = ? ORDER BY score DESC`, provider, slug)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var l Link
+		if err := rows.Scan(&l.Provider, &l.Slug, &l.OtherProvider, &l.OtherSlug, &l.Score, &l.Source, &l.CreatedAt); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
@@ -0,0 +1,254 @@
+package catalog
+
+import (
+	"fmt"
+	"komiku-scraper/internal/relevance"
+	"sort"
+	"strings"
+)
+
+// Sort orders understood by Search
+const (
+	SortRelevance = "relevance"
+	SortTitle     = "title"
+	SortUpdated   = "updated"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+	// fuzzyThreshold is the minimum trigram similarity for a fuzzy match
+	fuzzyThreshold = 0.45
+)
+
+// Query describes a catalogue search. Every field is optional.
+type Query struct {
+	Text     string   // matched as word prefixes against titles, alt titles and authors
+	Provider string   // ProviderKomiku or ProviderWinbu
+	Genres   []string // entries must carry all of them
+	Status   string
+	Type     string
+	Sort     string // SortRelevance (default with Text), SortTitle (default without) or SortUpdated
+	Limit    int
+	Offset   int
+}
+
+// Result is one page of search results
+type Result struct {
+	Items []Entry `json:"items"`
+	Total int     `json:"total"`
+	Fuzzy bool    `json:"fuzzy"` // nothing matched exactly, Items are the closest titles
+}
+
+// ValidSort reports whether sort is a known order
+func ValidSort(sort string) bool {
+	switch sort {
+	case "", SortRelevance, SortTitle, SortUpdated:
+		return true
+	}
+	return false
+}
+
+// Search finds entries whose titles start with the query words. When none
+// do, it retries with trigram similarity so misspelt titles still match.
+func (s *Store) Search(q Query) (*Result, error) {
+	if s == nil {
+		return &Result{Items: []Entry{}}, nil
+	}
+	if !ValidSort(q.Sort) {
+		return nil, fmt.Errorf("sort must be relevance, title or updated")
+	}
+	if q.Limit <= 0 {
+		q.Limit = defaultLimit
+	}
+	q.Limit = min(q.Limit, maxLimit)
+	q.Offset = max(q.Offset, 0)
+
+	text := relevance.Fold(q.Text)
+	if q.Sort == "" {
+		q.Sort = SortRelevance
+	}
+	if text == "" && q.Sort == SortRelevance {
+		q.Sort = SortTitle
+	}
+
+	where, args := filterClauses(q)
+	from := `entries e`
+	var order string
+	var orderArgs []any
+
+	if text != "" {
+		if s.fts {
+			from = `entries e JOIN entries_fts ON entries_fts.rowid = e.id`
+			where = append(where, `entries_fts MATCH ?`)
+			args = append(args, ftsQuery(relevance.Normalize(q.Text)))
+		} else {
+			for _, term := range strings.Fields(text) {
+				where = append(where, `e.search_text LIKE ?`)
+				args = append(args, "% "+term+"%")
+			}
+		}
+		if q.Sort == SortRelevance {
+			// Titles starting with the query first, then the best ranked
+			order = `CASE WHEN e.search_text LIKE ? THEN 0 ELSE 1 END, `
+			orderArgs = append(orderArgs, " "+text+"%")
+			if s.fts {
+				order += `bm25(entries_fts, 10.0, 5.0, 1.0)`
+			} else {
+				order += `length(e.title), e.title COLLATE NOCASE`
+			}
+		}
+	}
+	switch q.Sort {
+	case SortTitle:
+		order = `e.title COLLATE NOCASE`
+	case SortUpdated:
+		order = `e.updated_at DESC`
+	}
+
+	whereSQL := ""
+	if len(where) > 0 {
+		whereSQL = ` WHERE ` + strings.Join(where, ` AND `)
+	}
+
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM `+from+whereSQL, args...).Scan(&total); err != nil {
+		return nil, err
+	}
+	if total == 0 && text != "" {
+		return s.fuzzySearch(q, text)
+	}
+
+	rows, err := s.db.Query(`SELECT `+entryColumns+` FROM `+from+whereSQL+` ORDER BY `+order+` LIMIT ? OFFSET ?`,
+		append(append(args, orderArgs...), q.Limit, q.Offset)...)
+	if err != nil {
+		return nil, err
+	}
+	items, err := scanEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Items: items, Total: total}, nil
+}
+
+// fuzzySearch scores every entry passing the filters by trigram similarity
+// against its titles. The catalogue is a few thousand rows, small enough to
+// scan.
+func (s *Store) fuzzySearch(q Query, text string) (*Result, error) {
+	where, args := filterClauses(q)
+	whereSQL := ""
+	if len(where) > 0 {
+		whereSQL = ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	rows, err := s.db.Query(`SELECT e.search_text, `+entryColumns+` FROM entries e`+whereSQL, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type scored struct {
+		entry Entry
+		score float64
+	}
+	query := relevance.Trigrams(text)
+	var matches []scored
+	for rows.Next() {
+		var (
+			searchText                 string
+			e                          Entry
+			altTitles, genres, authors string
+		)
+		err := rows.Scan(&searchText, &e.Provider, &e.Slug, &e.Title, &altTitles, &genres, &authors,
+			&e.Status, &e.Type, &e.Thumb, &e.Endpoint, &e.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		best := 0.0
+		for _, line := range strings.Split(searchText, " | ") {
+			best = max(best, relevance.Similarity(query, relevance.Trigrams(strings.TrimSpace(line))))
+		}
+		if best >= fuzzyThreshold {
+			e.AltTitles = splitList(altTitles)
+			e.Genres = splitList(genres)
+			e.Authors = splitList(authors)
+			matches = append(matches, scored{e, best})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		switch q.Sort {
+		case SortTitle:
+			return strings.ToLower(matches[i].entry.Title) < strings.ToLower(matches[j].entry.Title)
+		case SortUpdated:
+			return matches[i].entry.UpdatedAt.After(matches[j].entry.UpdatedAt)
+		}
+		return matches[i].score > matches[j].score
+	})
+
+	result := &Result{Items: []Entry{}, Total: len(matches), Fuzzy: true}
+	for i := q.Offset; i < len(matches) && i < q.Offset+q.Limit; i++ {
+		result.Items = append(result.Items, matches[i].entry)
+	}
+	return result, nil
+}
+
+func filterClauses(q Query) ([]string, []any) {
+	var (
+		where []string
+		args  []any
+	)
+	if q.Provider != "" {
+		where = append(where, `e.provider = ?`)
+		args = append(args, strings.ToLower(q.Provider))
+	}
+	if q.Status != "" {
+		where = append(where, `e.status = ?`)
+		args = append(args, strings.ToLower(q.Status))
+	}
+	if q.Type != "" {
+		where = append(where, `e.type = ? COLLATE NOCASE`)
+		args = append(args, q.Type)
+	}
+	for _, g := range q.Genres {
+		if key := GenreKey(g); key != "" {
+			where = append(where, `e.genre_keys LIKE ?`)
+			args = append(args, "%|"+key+"|%")
+		}
+	}
+	return where, args
+}
+
+// ftsQuery turns normalized words into an FTS5 prefix query: "one"* "pie"*
+// matches "One Piece"
+func ftsQuery(text string) string {
+	terms := strings.Fields(text)
+	for i, term := range terms {
+		terms[i] = `"` + term + `"*`
+	}
+	return strings.Join(terms, " ")
+}
+
+// GenreKey returns the slug used to filter by genre: "Slice of Life" and
+// "slice-of-life" both give "slice-of-life"
+func GenreKey(genre string) string {
+	return strings.ReplaceAll(relevance.Normalize(genre), " ", "-")
+}
+
+// searchText is stored per entry for LIKE and fuzzy matching: each title and
+// the authors folded (see relevance.Fold), " | " separated, with a leading
+// space so every word is preceded by one
+func searchText(title string, altTitles, authors []string) string {
+	parts := []string{relevance.Fold(title)}
+	for _, alt := range altTitles {
+		if alt = relevance.Fold(alt); alt != "" {
+			parts = append(parts, alt)
+		}
+	}
+	if a := relevance.Fold(strings.Join(authors, " ")); a != "" {
+		parts = append(parts, a)
+	}
+	return " " + strings.Join(parts, " | ")
+}
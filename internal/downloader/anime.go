@@ -3,8 +3,12 @@ package downloader
 import (
 	"fmt"
 	"komiku-scraper/scraper/winbu"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -41,7 +45,8 @@ func (d *Downloader) SaveAnimeInfo(animeTitle, episodeTitle string, data *winbu.
 	fmt.Fprintf(file, "\n--- DOWNLOAD LINKS ---\n")
 	if len(data.DownloadLinks) > 0 {
 		for _, link := range data.DownloadLinks {
-			fmt.Fprintf(file, "[%s] %s: %s\n", link.Quality, link.Server, link.URL)
+			label := strings.Join(strings.Fields(link.Format+" "+link.Encoding+" "+link.Quality), " ")
+			fmt.Fprintf(file, "[%s] %s: %s\n", label, link.Server, DownloadTarget(link))
 		}
 	} else {
 		fmt.Fprintf(file, "No direct download links parsed found on page.\n")
@@ -50,3 +55,110 @@ func (d *Downloader) SaveAnimeInfo(animeTitle, episodeTitle string, data *winbu.
 	fmt.Printf("\n✅ Info saved to: %s\n", filename)
 	return nil
 }
+
+// DownloadEpisode downloads a direct video or HLS stream for an episode into
+// Downloads/Anime/Title/Episode and returns the saved file path
+func (d *Downloader) DownloadEpisode(animeTitle, episodeTitle, mediaURL, quality string) (string, error) {
+	saveDir := filepath.Join(d.BaseDir, "Anime", SanitizeFilename(animeTitle), SanitizeFilename(episodeTitle))
+	dest := filepath.Join(saveDir, SanitizeFilename(episodeTitle))
+
+	fmt.Printf("\nDownloading video to: %s\n", saveDir)
+
+	jobID := atomic.AddInt64(&memJobSeq, 1)
+	tracker := NewTracker(d.Bus, jobID, 0)
+
+	// Print progress inline from the event stream
+	events, cancel := d.Bus.Subscribe(jobID)
+	printed := make(chan struct{})
+	go func() {
+		defer close(printed)
+		for e := range events {
+			if e.Terminal() {
+				return
+			}
+			fmt.Printf("\rProgress: %d/%d parts, %.1f MB", e.ItemsDone, e.ItemsTotal, float64(e.BytesDone)/(1<<20))
+		}
+	}()
+
+	saved, err := d.DownloadVideo(mediaURL, dest, quality, tracker)
+	tracker.Finish(err)
+	cancel()
+	<-printed
+
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+	fmt.Printf("✅ Video saved to: %s\n", saved)
+	return saved, nil
+}
+
+// IsDirectMedia reports whether a URL points at a downloadable video file or
+// HLS playlist rather than an embed/host page
+func IsDirectMedia(mediaURL string) bool {
+	u, err := url.Parse(mediaURL)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(path.Ext(u.Path)) {
+	case ".mp4", ".mkv", ".webm", ".m3u8":
+		return true
+	}
+	return false
+}
+
+// ProbeDirectMedia is IsDirectMedia for URLs without a telling extension:
+// it asks the server and accepts video payloads and HLS playlists
+// recognised by Content-Type or their #EXTM3U header
+func (d *Downloader) ProbeDirectMedia(mediaURL string) bool {
+	if IsDirectMedia(mediaURL) {
+		return true
+	}
+	hls, contentType, err := d.sniffMedia(mediaURL)
+	if err != nil {
+		return false
+	}
+	return hls || strings.HasPrefix(strings.ToLower(contentType), "video/")
+}
+
+// SelectStreamOption returns the option whose Quality matches quality,
+// falling back to the first option
+func SelectStreamOption(opts []winbu.StreamOption, quality string) *winbu.StreamOption {
+	if len(opts) == 0 {
+		return nil
+	}
+	want := QualityHeight(quality)
+	for i := range opts {
+		if want != 0 && QualityHeight(opts[i].Quality) == want {
+			return &opts[i]
+		}
+	}
+	return &opts[0]
+}
+
+// SelectDownloadLink returns a direct-file download link for quality,
+// or nil if none of the links point at a video file
+func SelectDownloadLink(links []winbu.DownloadLink, quality string) *winbu.DownloadLink {
+	var fallback *winbu.DownloadLink
+	want := QualityHeight(quality)
+	for i := range links {
+		if !IsDirectMedia(DownloadTarget(links[i])) {
+			continue
+		}
+		if want == 0 || QualityHeight(links[i].Quality) == want {
+			return &links[i]
+		}
+		if fallback == nil {
+			fallback = &links[i]
+		}
+	}
+	return fallback
+}
+
+// DownloadTarget is the resolved file host URL of link when known
+func DownloadTarget(link winbu.DownloadLink) string {
+	if link.ResolvedURL != "" {
+		return link.ResolvedURL
+	}
+	return link.URL
+}
@@ -1,10 +1,18 @@
 package downloader
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"io"
 	"komiku-scraper/scraper/common"
+	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 )
@@ -13,6 +21,12 @@ import (
 type Downloader struct {
 	Client  *http.Client
 	BaseDir string
+	Store   *Store // nil when the queue database is unavailable
+	Bus     *Bus
+	Limiter *common.Limiter // shared with the scrapers, nil to disable
+
+	// Transcode is the default image conversion for new jobs
+	Transcode Transcode
 }
 
 // New creates a new Downloader instance
@@ -24,7 +38,11 @@ func New() *Downloader {
 	}
 
 	return &Downloader{
-		BaseDir: baseDir,
+		BaseDir:   baseDir,
+		Store:     NewStore(),
+		Bus:       NewBus(),
+		Transcode: TranscodeFromEnv(),
+		Limiter:   common.DefaultLimiter,
 		Client: &http.Client{
 			Timeout: 2 * time.Minute, // Longer timeout for large files
 			Transport: &http.Transport{
@@ -66,5 +84,153 @@ func (d *Downloader) GetRequest(url string) (*http.Response, error) {
 	}
 
 	req.Header.Set("User-Agent", common.ChromeAndroidUserAgent)
-	return d.Client.Do(req)
+	return d.do(d.Client, req)
+}
+
+// do sends req once the upstream limiter lets a background request to its
+// host start, so downloads queue behind API traffic to the same site
+func (d *Downloader) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	if d.Limiter != nil {
+		release, err := d.Limiter.Acquire(req.Context(), req.URL.Host, common.PriorityBackground)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	}
+	return client.Do(req)
+}
+
+// fileResult describes a file written by the downloader
+type fileResult struct {
+	Path     string
+	Size     int64
+	Checksum string // sha256 hex
+}
+
+// downloadImage fetches an image, detects its real format and stores it as
+// stem plus the matching extension, transcoding first when t asks for it.
+// Bytes received are also copied to progress as they arrive. tag marks the
+// temp file, see writeAtomic.
+func (d *Downloader) downloadImage(url, stem, tag string, t Transcode, progress io.Writer) (fileResult, error) {
+	resp, err := d.GetRequest(url)
+	if err != nil {
+		return fileResult{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fileResult{}, fmt.Errorf("status code %d", resp.StatusCode)
+	}
+
+	body := bufio.NewReader(resp.Body)
+	head, _ := body.Peek(512)
+	format := DetectImageFormat(resp.Header.Get("Content-Type"), head)
+
+	// AVIF and unknown payloads cannot be decoded, so they are kept as served
+	convert := t.Format != "" && t.Format != format && format != "" && format != FormatAVIF
+	if !convert {
+		res, err := writeAtomic(stem+imageExt(format), tag, body, progress, resp.ContentLength)
+		return res, err
+	}
+
+	data, err := io.ReadAll(io.TeeReader(body, progress))
+	if err != nil {
+		return fileResult{}, err
+	}
+	if resp.ContentLength > 0 && int64(len(data)) != resp.ContentLength {
+		return fileResult{}, fmt.Errorf("size mismatch: got %d bytes, expected %d", len(data), resp.ContentLength)
+	}
+
+	converted, err := transcodeImage(data, t.Format, t.Quality)
+	if err != nil {
+		log.Printf("[Downloader] Keeping original %s for %s: %v", format, url, err)
+		return writeAtomic(stem+imageExt(format), tag, bytes.NewReader(data), nil, -1)
+	}
+	return writeAtomic(stem+imageExt(t.Format), tag, bytes.NewReader(converted), nil, -1)
+}
+
+// writeAtomic streams r into a temp file next to path and renames it into
+// place only once complete. expected, when positive, is the required size.
+// A non-empty tag is put in the temp file name so its owner can find it
+// again after a crash, see removeStaleParts.
+func writeAtomic(path, tag string, r io.Reader, progress io.Writer, expected int64) (fileResult, error) {
+	if progress == nil {
+		progress = io.Discard
+	}
+
+	pattern := filepath.Base(path) + ".*.part"
+	if tag != "" {
+		pattern = filepath.Base(path) + "." + tag + ".*.part"
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), pattern)
+	if err != nil {
+		return fileResult{}, err
+	}
+	// Clean up the temp file on any failure path
+	defer os.Remove(tmp.Name())
+
+	hasher := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hasher, progress), r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fileResult{}, err
+	}
+
+	if expected > 0 && size != expected {
+		return fileResult{}, fmt.Errorf("size mismatch: got %d bytes, expected %d", size, expected)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fileResult{}, err
+	}
+
+	return fileResult{Path: path, Size: size, Checksum: hex.EncodeToString(hasher.Sum(nil))}, nil
+}
+
+// removeStaleParts deletes temp files tagged with tag that an earlier run
+// left in dir when the process was killed mid-write. Temp files of other
+// jobs writing to the same directory carry other tags and are kept.
+func removeStaleParts(dir, tag string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".part") || !strings.Contains(name, "."+tag+".") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			log.Printf("[Downloader] Failed to remove %s: %v", path, err)
+		}
+	}
+}
+
+// verifyFile checks that a file on disk matches the recorded size and checksum
+func verifyFile(path string, size int64, checksum string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() != size || checksum == "" {
+		return false
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return false
+	}
+	return hex.EncodeToString(hasher.Sum(nil)) == checksum
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
 }
@@ -0,0 +1,164 @@
+package downloader
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif" // register GIF decoder
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	_ "golang.org/x/image/webp" // register WebP decoder (pure Go)
+)
+
+// Image formats recognised by the downloader
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatWebP = "webp"
+	FormatGIF  = "gif"
+	FormatAVIF = "avif"
+)
+
+// DefaultImageQuality is used for lossy encodes when no quality is given
+const DefaultImageQuality = 85
+
+// formatExt maps image formats to file extensions
+var formatExt = map[string]string{
+	FormatJPEG: ".jpg",
+	FormatPNG:  ".png",
+	FormatWebP: ".webp",
+	FormatGIF:  ".gif",
+	FormatAVIF: ".avif",
+}
+
+// Transcode describes an optional conversion applied to downloaded images.
+// An empty Format keeps images in whatever format the site serves.
+type Transcode struct {
+	Format  string
+	Quality int // 1-100, lossy formats only
+}
+
+// TranscodeFromEnv reads DOWNLOAD_IMAGE_FORMAT and DOWNLOAD_IMAGE_QUALITY
+func TranscodeFromEnv() Transcode {
+	format, err := ParseImageFormat(os.Getenv("DOWNLOAD_IMAGE_FORMAT"))
+	if err != nil {
+		format = ""
+	}
+	quality, _ := strconv.Atoi(os.Getenv("DOWNLOAD_IMAGE_QUALITY"))
+	return Transcode{Format: format, Quality: quality}
+}
+
+// ParseImageFormat normalises a user-supplied transcode target.
+// An empty string means "keep original".
+func ParseImageFormat(s string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "original":
+		return "", nil
+	case "jpg", "jpeg":
+		return FormatJPEG, nil
+	case "png":
+		return FormatPNG, nil
+	case "webp":
+		if !webpEncodeSupported {
+			return "", fmt.Errorf("webp output requires a cgo build (use jpeg or png)")
+		}
+		return FormatWebP, nil
+	default:
+		return "", fmt.Errorf("unsupported image format %q (use jpeg, png or webp)", s)
+	}
+}
+
+// DetectImageFormat identifies an image from its leading bytes, falling back
+// to the Content-Type header. Magic bytes win because komiku's CDN frequently
+// labels WebP and AVIF as image/jpeg.
+func DetectImageFormat(contentType string, head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte{0xFF, 0xD8, 0xFF}):
+		return FormatJPEG
+	case bytes.HasPrefix(head, []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}):
+		return FormatPNG
+	case bytes.HasPrefix(head, []byte("GIF87a")), bytes.HasPrefix(head, []byte("GIF89a")):
+		return FormatGIF
+	case len(head) >= 12 && string(head[0:4]) == "RIFF" && string(head[8:12]) == "WEBP":
+		return FormatWebP
+	case len(head) >= 12 && string(head[4:8]) == "ftyp" && (string(head[8:12]) == "avif" || string(head[8:12]) == "avis"):
+		return FormatAVIF
+	}
+
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	switch mediaType {
+	case "image/jpeg", "image/jpg", "image/pjpeg":
+		return FormatJPEG
+	case "image/png":
+		return FormatPNG
+	case "image/gif":
+		return FormatGIF
+	case "image/webp":
+		return FormatWebP
+	case "image/avif":
+		return FormatAVIF
+	}
+	return ""
+}
+
+// imageExt returns the file extension for a format, defaulting to .jpg
+func imageExt(format string) string {
+	if ext, ok := formatExt[format]; ok {
+		return ext
+	}
+	return ".jpg"
+}
+
+// trimImageExt strips a known image extension so a path can be used as a stem
+func trimImageExt(path string) string {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, known := range formatExt {
+		if ext == known {
+			return strings.TrimSuffix(path, filepath.Ext(path))
+		}
+	}
+	return path
+}
+
+// existingImage returns a completed image for stem regardless of extension
+func existingImage(stem string) string {
+	for _, ext := range formatExt {
+		if fileExists(stem + ext) {
+			return stem + ext
+		}
+	}
+	return ""
+}
+
+// transcodeImage re-encodes data into the target format
+func transcodeImage(data []byte, target string, quality int) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %v", err)
+	}
+
+	if quality <= 0 || quality > 100 {
+		quality = DefaultImageQuality
+	}
+
+	buf := new(bytes.Buffer)
+	switch target {
+	case FormatJPEG:
+		err = jpeg.Encode(buf, img, &jpeg.Options{Quality: quality})
+	case FormatPNG:
+		err = png.Encode(buf, img)
+	case FormatWebP:
+		err = encodeWebP(buf, img, quality)
+	default:
+		return nil, fmt.Errorf("unsupported target format %q", target)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encode %s: %v", target, err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,18 @@
+//go:build cgo
+
+package downloader
+
+import (
+	"image"
+	"io"
+
+	"github.com/chai2010/webp"
+)
+
+// webpEncodeSupported reports whether transcodeImage can produce WebP
+const webpEncodeSupported = true
+
+// encodeWebP encodes img with libwebp, which is only available with cgo
+func encodeWebP(w io.Writer, img image.Image, quality int) error {
+	return webp.Encode(w, img, &webp.Options{Quality: float32(quality)})
+}
@@ -0,0 +1,17 @@
+//go:build !cgo
+
+package downloader
+
+import (
+	"errors"
+	"image"
+	"io"
+)
+
+// webpEncodeSupported reports whether transcodeImage can produce WebP
+const webpEncodeSupported = false
+
+// encodeWebP is unavailable without cgo; WebP input still decodes in pure Go
+func encodeWebP(w io.Writer, img image.Image, quality int) error {
+	return errors.New("webp encoding requires cgo")
+}
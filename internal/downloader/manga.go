@@ -2,53 +2,173 @@ package downloader
 
 import (
 	"fmt"
-	"io"
 	"komiku-scraper/scraper/komiku"
 	"log"
-	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// memJobSeq numbers jobs when no queue database is available
+var memJobSeq int64
+
+// ErrJobClaimed is returned by RunJob for a job another process is running
+var ErrJobClaimed = fmt.Errorf("job is already running in another process")
+
 // DownloadChapter downloads all images from a chapter
 func (d *Downloader) DownloadChapter(mangaTitle, chapterTitle string, images []komiku.ChapterImage) error {
+	job, items, err := d.EnqueueChapter(mangaTitle, chapterTitle, images, d.Transcode)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\nDownloading to: %s\n", job.Dir)
+	fmt.Printf("Total Images: %d\n", len(items))
+
+	// Print progress inline from the event stream
+	events, cancel := d.Bus.Subscribe(job.ID)
+	printed := make(chan struct{})
+	go func() {
+		defer close(printed)
+		for e := range events {
+			if e.Terminal() {
+				return
+			}
+			finished := e.ItemsDone + e.ItemsFailed
+			fmt.Printf("\rProgress: %d/%d images [%.0f%%]", finished, e.ItemsTotal, float64(finished)/float64(e.ItemsTotal)*100)
+		}
+	}()
+
+	err = d.RunJob(job, items)
+	cancel()
+	<-printed
+
+	fmt.Println() // New line after progress
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("✅ Download Complete!")
+	return nil
+}
+
+// EnqueueChapter records a chapter download job and its images without
+// starting it. Images are converted according to t once downloaded.
+func (d *Downloader) EnqueueChapter(mangaTitle, chapterTitle string, images []komiku.ChapterImage, t Transcode) (*Job, []Item, error) {
 	safeMangaTitle := SanitizeFilename(mangaTitle)
 	safeChapterTitle := SanitizeFilename(chapterTitle)
 
 	// Create structure: Downloads/Manga/Title/Chapter
 	saveDir := filepath.Join(d.BaseDir, "Manga", safeMangaTitle, safeChapterTitle)
 	if err := EnsureDir(saveDir); err != nil {
-		return fmt.Errorf("failed to create directory: %v", err)
+		return nil, nil, fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	job := &Job{
+		Kind:    "manga",
+		Title:   mangaTitle,
+		Chapter: chapterTitle,
+		Dir:     saveDir,
+		Format:  t.Format,
+		Quality: t.Quality,
 	}
 
-	fmt.Printf("\nDownloading to: %s\n", saveDir)
-	fmt.Printf("Total Images: %d\n", len(images))
+	items := make([]Item, 0, len(images))
+	for i, img := range images {
+		// Generate filename stem: 001, 002, ... The extension is added once
+		// the real image format is known.
+		items = append(items, Item{
+			Index: i,
+			URL:   img.URL,
+			Path:  filepath.Join(saveDir, fmt.Sprintf("%03d", i+1)),
+		})
+	}
+
+	// Pages an earlier job already saved, and that still verify, are taken
+	// over instead of downloaded again
+	done, err := d.Store.DoneItems(saveDir, t.Format, t.Quality)
+	if err != nil {
+		log.Printf("[Downloader] Failed to look up earlier downloads of %s: %v", saveDir, err)
+	}
+	for i := range items {
+		prev, ok := done[items[i].Index]
+		if ok && prev.URL == items[i].URL && verifyFile(prev.Path, prev.Size, prev.Checksum) {
+			items[i].Path, items[i].Size, items[i].Checksum = prev.Path, prev.Size, prev.Checksum
+			items[i].State = StateDone
+		}
+	}
+
+	if err := d.Store.CreateJob(job, items); err != nil {
+		return nil, nil, fmt.Errorf("failed to record job: %v", err)
+	}
+	if job.ID == 0 {
+		// No queue database: hand out process-local IDs so progress
+		// subscribers of concurrent jobs don't collide
+		job.ID = atomic.AddInt64(&memJobSeq, 1)
+	}
+	return job, items, nil
+}
+
+// RunJob downloads every unfinished item of a job, publishing progress on
+// d.Bus and recording the outcome in the store. It returns ErrJobClaimed
+// without doing anything if another process is running the job.
+func (d *Downloader) RunJob(job *Job, items []Item) error {
+	claimed, err := d.Store.ClaimJob(job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to claim job: %v", err)
+	}
+	if !claimed {
+		return ErrJobClaimed
+	}
+	// Temp files of this job left by a crash can never be completed
+	tag := partTag(job.ID)
+	removeStaleParts(job.Dir, tag)
+
+	tracker := NewTracker(d.Bus, job.ID, len(items))
+	transcode := Transcode{Format: job.Format, Quality: job.Quality}
+	// Make the job visible through Last before the first item completes
+	tracker.publish(tracker.Snapshot())
+
+	// Publish byte-level progress between item completions and keep the
+	// claim on the job alive
+	stopTicker := make(chan struct{})
+	tickerDone := make(chan struct{})
+	go func() {
+		defer close(tickerDone)
+		ticker := time.NewTicker(1 * time.Second)
+		defer ticker.Stop()
+		heartbeat := time.NewTicker(jobLease / 4)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				tracker.publish(tracker.Snapshot())
+			case <-heartbeat.C:
+				if err := d.Store.TouchJob(job.ID); err != nil {
+					log.Printf("[Downloader] Failed to renew job %d: %v", job.ID, err)
+				}
+			case <-stopTicker:
+				return
+			}
+		}
+	}()
 
 	var wg sync.WaitGroup
 	semaphore := make(chan struct{}, 5) // Limit to 5 concurrent downloads
-	errorsChan := make(chan error, len(images))
-
-	completed := 0
-	total := len(images)
+	errorsChan := make(chan error, len(items))
 
-	for i, img := range images {
+	for i := range items {
 		wg.Add(1)
-		go func(idx int, imgUrl string) {
+		go func(item *Item) {
 			defer wg.Done()
 			semaphore <- struct{}{}        // Acquire token
 			defer func() { <-semaphore }() // Release token
 
-			// Generate filename: 01.jpg, 02.jpg, ...
-			ext := ".jpg" // default
-			// You could detect extension from URL or Content-Type if needed
-			filename := fmt.Sprintf("%03d%s", idx+1, ext)
-			filePath := filepath.Join(saveDir, filename)
-
 			// Retry logic
 			var err error
 			for attempt := 0; attempt < 3; attempt++ {
-				err = d.downloadFile(imgUrl, filePath)
+				err = d.downloadItem(item, tag, transcode, tracker)
 				if err == nil {
 					break
 				}
@@ -56,56 +176,85 @@ func (d *Downloader) DownloadChapter(mangaTitle, chapterTitle string, images []k
 			}
 
 			if err != nil {
-				log.Printf("Failed to download image %d: %v", idx+1, err)
+				log.Printf("Failed to download image %d: %v", item.Index+1, err)
+				item.State = StateFailed
+				item.Error = err.Error()
 				errorsChan <- err
+				tracker.ItemFailed(err)
 			} else {
-				completed++
-				// Update progress inline
-				fmt.Printf("\rProgress: %d/%d images [%.0f%%]", completed, total, float64(completed)/float64(total)*100)
+				item.State = StateDone
+				item.Error = ""
+				tracker.ItemDone()
 			}
-		}(i, img.URL)
+			if err := d.Store.UpdateItem(item); err != nil {
+				log.Printf("[Downloader] Failed to update item %d: %v", item.ID, err)
+			}
+		}(&items[i])
 	}
 
 	wg.Wait()
 	close(errorsChan)
 
+	// Stop periodic updates so nothing is published after the terminal event
+	close(stopTicker)
+	<-tickerDone
+
 	// Check for errors
 	errCount := 0
 	for range errorsChan {
 		errCount++
 	}
 
-	fmt.Println() // New line after progress
 	if errCount > 0 {
-		return fmt.Errorf("finished with %d errors", errCount)
+		err := fmt.Errorf("finished with %d errors", errCount)
+		stored := d.Store.SetJobState(job.ID, StateFailed, err.Error())
+		tracker.Finish(err)
+		d.forgetStored(job.ID, stored)
+		return err
 	}
 
-	fmt.Println("✅ Download Complete!")
+	stored := d.Store.SetJobState(job.ID, StateDone, "")
+	tracker.Finish(nil)
+	d.forgetStored(job.ID, stored)
 	return nil
 }
 
-func (d *Downloader) downloadFile(url, filepath string) error {
-	// Check if file already exists
-	if _, err := os.Stat(filepath); err == nil {
-		return nil // Skip existing
+// forgetStored drops a finished job's last event from the bus once its
+// outcome is in the queue database, which handlers rebuild it from
+func (d *Downloader) forgetStored(jobID int64, storeErr error) {
+	if d.Store != nil && storeErr == nil {
+		d.Bus.Forget(jobID)
 	}
+}
 
-	resp, err := d.GetRequest(url)
-	if err != nil {
-		return err
+// downloadItem fetches a single item unless a verified copy already exists
+func (d *Downloader) downloadItem(item *Item, tag string, t Transcode, tracker *Tracker) error {
+	if item.State == StateDone && verifyFile(item.Path, item.Size, item.Checksum) {
+		return nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		return fmt.Errorf("status code %d", resp.StatusCode)
+	stem := trimImageExt(item.Path)
+
+	// Without a queue database we cannot verify, but files are only ever
+	// renamed into place once complete, so an existing file is trustworthy.
+	if d.Store == nil {
+		if path := existingImage(stem); path != "" {
+			item.Path = path
+			return nil
+		}
 	}
 
-	out, err := os.Create(filepath)
+	res, err := d.downloadImage(item.URL, stem, tag, t, tracker)
 	if err != nil {
 		return err
 	}
-	defer out.Close()
+	item.Path = res.Path
+	item.Size = res.Size
+	item.Checksum = res.Checksum
+	return nil
+}
 
-	_, err = io.Copy(out, resp.Body)
-	return err
+// partTag marks the temp files of a job
+func partTag(jobID int64) string {
+	return fmt.Sprintf("job%d", jobID)
 }
@@ -0,0 +1,257 @@
+package downloader
+
+import (
+	"sync"
+	"time"
+)
+
+// Progress event types
+const (
+	EventProgress   = "progress"
+	EventItemDone   = "item_done"
+	EventItemFailed = "item_failed"
+	EventJobDone    = "job_done"
+	EventJobFailed  = "job_failed"
+)
+
+// Event is a progress update for a single download job
+type Event struct {
+	JobID       int64     `json:"job_id"`
+	Type        string    `json:"type"`
+	ItemsTotal  int       `json:"items_total"`
+	ItemsDone   int       `json:"items_done"`
+	ItemsFailed int       `json:"items_failed"`
+	BytesDone   int64     `json:"bytes_done"`
+	ETASeconds  float64   `json:"eta_seconds"`
+	Error       string    `json:"error,omitempty"`
+	Time        time.Time `json:"time"`
+}
+
+// terminalTTL is how long a finished job's last event stays on the bus when
+// nothing else removes it
+const terminalTTL = time.Hour
+
+// Terminal reports whether no further events will follow for the job
+func (e Event) Terminal() bool {
+	return e.Type == EventJobDone || e.Type == EventJobFailed
+}
+
+// Bus fans out progress events to subscribers of a job
+type Bus struct {
+	mu   sync.RWMutex
+	subs map[int64]map[chan Event]struct{}
+	last map[int64]Event
+}
+
+// NewBus creates an empty event bus
+func NewBus() *Bus {
+	return &Bus{
+		subs: make(map[int64]map[chan Event]struct{}),
+		last: make(map[int64]Event),
+	}
+}
+
+// Subscribe returns a channel receiving events for jobID and a cancel
+// function that unsubscribes and closes the channel
+func (b *Bus) Subscribe(jobID int64) (<-chan Event, func()) {
+	ch := make(chan Event, 32)
+
+	b.mu.Lock()
+	if b.subs[jobID] == nil {
+		b.subs[jobID] = make(map[chan Event]struct{})
+	}
+	b.subs[jobID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	cancel := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[jobID][ch]; ok {
+			delete(b.subs[jobID], ch)
+			close(ch)
+			if len(b.subs[jobID]) == 0 {
+				delete(b.subs, jobID)
+			}
+		}
+	}
+	return ch, cancel
+}
+
+// Publish delivers an event to all subscribers without blocking.
+// Slow subscribers miss intermediate events but always see the latest state
+// through Last. Terminal events are always delivered: the oldest buffered
+// event is dropped to make room, and the channel is closed afterwards since
+// nothing else will follow.
+func (b *Bus) Publish(e Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Terminal events are kept so finished jobs can still be looked up when
+	// there is no queue database. Jobs that are in the database are dropped
+	// through Forget; the rest expire after terminalTTL.
+	if e.Terminal() {
+		b.expire(e.Time.Add(-terminalTTL))
+	}
+	b.last[e.JobID] = e
+
+	for ch := range b.subs[e.JobID] {
+		if !e.Terminal() {
+			select {
+			case ch <- e:
+			default:
+			}
+			continue
+		}
+
+		// Only Publish sends and it holds b.mu, so once a slot is free the
+		// send cannot block
+		select {
+		case ch <- e:
+		default:
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- e
+		}
+		close(ch)
+	}
+	if e.Terminal() {
+		delete(b.subs, e.JobID)
+	}
+}
+
+// expire drops terminal events published before cutoff; caller holds b.mu
+func (b *Bus) expire(cutoff time.Time) {
+	for id, e := range b.last {
+		if e.Terminal() && e.Time.Before(cutoff) {
+			delete(b.last, id)
+		}
+	}
+}
+
+// Forget drops the last event of a job whose outcome is stored elsewhere
+func (b *Bus) Forget(jobID int64) {
+	b.mu.Lock()
+	delete(b.last, jobID)
+	b.mu.Unlock()
+}
+
+// Last returns the most recent event of a job, including the terminal
+// event once it has finished, until it is forgotten or expires
+func (b *Bus) Last(jobID int64) (Event, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	e, ok := b.last[jobID]
+	return e, ok
+}
+
+// Tracker accumulates progress for one job; safe for concurrent use
+type Tracker struct {
+	mu      sync.Mutex
+	bus     *Bus
+	jobID   int64
+	total   int
+	done    int
+	failed  int
+	bytes   int64
+	started time.Time
+}
+
+// NewTracker starts tracking a job with total items
+func NewTracker(bus *Bus, jobID int64, total int) *Tracker {
+	return &Tracker{
+		bus:     bus,
+		jobID:   jobID,
+		total:   total,
+		started: time.Now(),
+	}
+}
+
+// SetTotal updates the item count once it is known (e.g. HLS segments)
+func (t *Tracker) SetTotal(total int) {
+	t.mu.Lock()
+	t.total = total
+	t.mu.Unlock()
+}
+
+// Write counts downloaded bytes so a Tracker can sit behind an io.MultiWriter
+func (t *Tracker) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	t.bytes += int64(len(p))
+	t.mu.Unlock()
+	return len(p), nil
+}
+
+// ItemDone records a completed item and publishes the new state
+func (t *Tracker) ItemDone() Event {
+	t.mu.Lock()
+	t.done++
+	e := t.snapshot(EventItemDone, "")
+	t.mu.Unlock()
+
+	t.publish(e)
+	return e
+}
+
+// ItemFailed records a failed item and publishes the new state
+func (t *Tracker) ItemFailed(err error) Event {
+	t.mu.Lock()
+	t.failed++
+	e := t.snapshot(EventItemFailed, err.Error())
+	t.mu.Unlock()
+
+	t.publish(e)
+	return e
+}
+
+// Finish publishes the terminal event for the job
+func (t *Tracker) Finish(err error) Event {
+	t.mu.Lock()
+	var e Event
+	if err != nil {
+		e = t.snapshot(EventJobFailed, err.Error())
+	} else {
+		e = t.snapshot(EventJobDone, "")
+	}
+	t.mu.Unlock()
+
+	t.publish(e)
+	return e
+}
+
+// Snapshot returns the current progress without publishing it
+func (t *Tracker) Snapshot() Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.snapshot(EventProgress, "")
+}
+
+// snapshot builds an event from the current counters; caller holds t.mu
+func (t *Tracker) snapshot(eventType, errMsg string) Event {
+	e := Event{
+		JobID:       t.jobID,
+		Type:        eventType,
+		ItemsTotal:  t.total,
+		ItemsDone:   t.done,
+		ItemsFailed: t.failed,
+		BytesDone:   t.bytes,
+		Error:       errMsg,
+		Time:        time.Now(),
+	}
+
+	// ETA extrapolates the average time per finished item
+	finished := t.done + t.failed
+	if finished > 0 && finished < t.total {
+		perItem := time.Since(t.started).Seconds() / float64(finished)
+		e.ETASeconds = perItem * float64(t.total-finished)
+	}
+	return e
+}
+
+func (t *Tracker) publish(e Event) {
+	if t.bus != nil {
+		t.bus.Publish(e)
+	}
+}
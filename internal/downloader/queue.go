@@ -0,0 +1,60 @@
+package downloader
+
+import (
+	"log"
+	"time"
+)
+
+// ResumeUnfinished restarts jobs that were pending or running when the
+// previous process stopped. Items already verified on disk are skipped.
+// Jobs another process holds are left to it; those are tried once more
+// after jobLease, in case their owner had just crashed.
+func (d *Downloader) ResumeUnfinished() {
+	jobs, err := d.Store.UnfinishedJobs()
+	if err != nil {
+		log.Printf("[Downloader] Failed to load unfinished jobs: %v", err)
+		return
+	}
+
+	var held []Job
+	for i := range jobs {
+		if d.resume(&jobs[i]) == ErrJobClaimed {
+			held = append(held, jobs[i])
+		}
+	}
+	if len(held) == 0 {
+		return
+	}
+
+	time.Sleep(jobLease)
+	for i := range held {
+		if d.resume(&held[i]) == ErrJobClaimed {
+			log.Printf("[Downloader] Job %d is run by another process, leaving it", held[i].ID)
+		}
+	}
+}
+
+// resume runs one unfinished job and returns RunJob's error
+func (d *Downloader) resume(job *Job) error {
+	items, err := d.Store.Items(job.ID)
+	if err != nil {
+		log.Printf("[Downloader] Failed to load items for job %d: %v", job.ID, err)
+		return err
+	}
+
+	if err := EnsureDir(job.Dir); err != nil {
+		log.Printf("[Downloader] Failed to recreate %s: %v", job.Dir, err)
+		d.Store.SetJobState(job.ID, StateFailed, err.Error())
+		return err
+	}
+
+	err = d.RunJob(job, items)
+	switch {
+	case err == ErrJobClaimed:
+	case err != nil:
+		log.Printf("[Downloader] Job %d failed: %v", job.ID, err)
+	default:
+		log.Printf("[Downloader] Resumed job %d: %s - %s (%d items)", job.ID, job.Title, job.Chapter, len(items))
+	}
+	return err
+}
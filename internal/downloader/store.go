@@ -0,0 +1,314 @@
+package downloader
+
+import (
+	"database/sql"
+	"log"
+	"os"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Job and item states
+const (
+	StatePending = "pending"
+	StateRunning = "running"
+	StateDone    = "done"
+	StateFailed  = "failed"
+)
+
+// jobLease is how long a running job stays claimed without a heartbeat.
+// Running jobs whose owner stopped touching them for longer are taken over.
+const jobLease = 2 * time.Minute
+
+// Job represents a queued download (e.g. one manga chapter)
+type Job struct {
+	ID        int64
+	Kind      string // "manga"
+	Title     string
+	Chapter   string
+	Dir       string
+	Format    string // image transcode target, empty keeps originals
+	Quality   int
+	State     string
+	Error     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Item represents a single file belonging to a job
+type Item struct {
+	ID       int64
+	JobID    int64
+	Index    int
+	URL      string
+	Path     string // filename stem until the item completes
+	State    string
+	Size     int64
+	Checksum string // sha256 hex of the completed file
+	Error    string
+}
+
+// Store persists download jobs and items in SQLite so they survive restarts
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore opens (or creates) the download queue database
+func NewStore() *Store {
+	dbPath := os.Getenv("DOWNLOAD_DB_PATH")
+	if dbPath == "" {
+		dbPath = "./downloads.db"
+	}
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		log.Printf("[Downloader] Failed to open SQLite: %v", err)
+		return nil
+	}
+
+	schema := `
+	CREATE TABLE IF NOT EXISTS jobs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL,
+		title TEXT NOT NULL,
+		chapter TEXT NOT NULL,
+		dir TEXT NOT NULL,
+		format TEXT NOT NULL DEFAULT '',
+		quality INTEGER NOT NULL DEFAULT 0,
+		state TEXT NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS items (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		job_id INTEGER NOT NULL REFERENCES jobs(id),
+		idx INTEGER NOT NULL,
+		url TEXT NOT NULL,
+		path TEXT NOT NULL,
+		state TEXT NOT NULL,
+		size INTEGER NOT NULL DEFAULT 0,
+		checksum TEXT NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX IF NOT EXISTS idx_jobs_state ON jobs(state);
+	CREATE INDEX IF NOT EXISTS idx_items_job ON items(job_id);
+	`
+
+	if _, err := db.Exec(schema); err != nil {
+		log.Printf("[Downloader] Failed to create schema: %v", err)
+		return nil
+	}
+
+	// Columns added after the first release; errors mean they already exist
+	migrations := []string{
+		`ALTER TABLE jobs ADD COLUMN format TEXT NOT NULL DEFAULT ''`,
+		`ALTER TABLE jobs ADD COLUMN quality INTEGER NOT NULL DEFAULT 0`,
+	}
+	for _, m := range migrations {
+		db.Exec(m)
+	}
+
+	// SQLite only allows one writer; serialize access from worker goroutines
+	db.SetMaxOpenConns(1)
+
+	return &Store{db: db}
+}
+
+// CreateJob inserts a job and its items, filling in the generated IDs
+func (s *Store) CreateJob(job *Job, items []Item) error {
+	now := time.Now()
+	job.State = StatePending
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	if s == nil {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`
+		INSERT INTO jobs (kind, title, chapter, dir, format, quality, state, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, job.Kind, job.Title, job.Chapter, job.Dir, job.Format, job.Quality, job.State, job.CreatedAt, job.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	job.ID, _ = res.LastInsertId()
+
+	for i := range items {
+		items[i].JobID = job.ID
+		// Items reused from an earlier job arrive done
+		if items[i].State != StateDone {
+			items[i].State = StatePending
+		}
+		res, err := tx.Exec(`
+			INSERT INTO items (job_id, idx, url, path, state, size, checksum)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, items[i].JobID, items[i].Index, items[i].URL, items[i].Path, items[i].State, items[i].Size, items[i].Checksum)
+		if err != nil {
+			return err
+		}
+		items[i].ID, _ = res.LastInsertId()
+	}
+
+	return tx.Commit()
+}
+
+// SetJobState updates a job's state and error message
+func (s *Store) SetJobState(id int64, state, errMsg string) error {
+	if s == nil {
+		return nil
+	}
+	_, err := s.db.Exec(`UPDATE jobs SET state = ?, error = ?, updated_at = ? WHERE id = ?`,
+		state, errMsg, time.Now(), id)
+	return err
+}
+
+// ClaimJob marks a job running for this process. It fails for jobs that are
+// finished or that another process is running, so two processes sharing the
+// database never run the same job. Without a database every claim succeeds.
+func (s *Store) ClaimJob(id int64) (bool, error) {
+	if s == nil {
+		return true, nil
+	}
+	now := time.Now()
+	res, err := s.db.Exec(`
+		UPDATE jobs SET state = ?, error = '', updated_at = ?
+		WHERE id = ? AND (state = ? OR (state = ? AND updated_at < ?))
+	`, StateRunning, now, id, StatePending, StateRunning, now.Add(-jobLease))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// TouchJob renews the claim on a running job
+func (s *Store) TouchJob(id int64) error {
+	if s == nil {
+		return nil
+	}
+	_, err := s.db.Exec(`UPDATE jobs SET updated_at = ? WHERE id = ? AND state = ?`, time.Now(), id, StateRunning)
+	return err
+}
+
+// UpdateItem persists the state, size and checksum of an item
+func (s *Store) UpdateItem(item *Item) error {
+	if s == nil {
+		return nil
+	}
+	_, err := s.db.Exec(`UPDATE items SET path = ?, state = ?, size = ?, checksum = ?, error = ? WHERE id = ?`,
+		item.Path, item.State, item.Size, item.Checksum, item.Error, item.ID)
+	return err
+}
+
+// GetJob returns a single job by ID
+func (s *Store) GetJob(id int64) (*Job, error) {
+	if s == nil {
+		return nil, sql.ErrNoRows
+	}
+	row := s.db.QueryRow(`
+		SELECT id, kind, title, chapter, dir, format, quality, state, error, created_at, updated_at
+		FROM jobs WHERE id = ?
+	`, id)
+
+	var job Job
+	if err := row.Scan(&job.ID, &job.Kind, &job.Title, &job.Chapter, &job.Dir, &job.Format, &job.Quality, &job.State, &job.Error, &job.CreatedAt, &job.UpdatedAt); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Items returns all items of a job ordered by index
+func (s *Store) Items(jobID int64) ([]Item, error) {
+	if s == nil {
+		return nil, nil
+	}
+	rows, err := s.db.Query(`
+		SELECT id, job_id, idx, url, path, state, size, checksum, error
+		FROM items WHERE job_id = ? ORDER BY idx
+	`, jobID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []Item
+	for rows.Next() {
+		var it Item
+		if err := rows.Scan(&it.ID, &it.JobID, &it.Index, &it.URL, &it.Path, &it.State, &it.Size, &it.Checksum, &it.Error); err != nil {
+			return nil, err
+		}
+		items = append(items, it)
+	}
+	return items, rows.Err()
+}
+
+// DoneItems returns the completed items of earlier jobs that saved into dir
+// with the same image conversion, newest job first per index
+func (s *Store) DoneItems(dir, format string, quality int) (map[int]Item, error) {
+	if s == nil {
+		return nil, nil
+	}
+	rows, err := s.db.Query(`
+		SELECT items.id, items.job_id, items.idx, items.url, items.path, items.state, items.size, items.checksum, items.error
+		FROM items JOIN jobs ON jobs.id = items.job_id
+		WHERE jobs.dir = ? AND jobs.format = ? AND jobs.quality = ? AND items.state = ?
+		ORDER BY items.job_id DESC
+	`, dir, format, quality, StateDone)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	done := make(map[int]Item)
+	for rows.Next() {
+		var it Item
+		if err := rows.Scan(&it.ID, &it.JobID, &it.Index, &it.URL, &it.Path, &it.State, &it.Size, &it.Checksum, &it.Error); err != nil {
+			return nil, err
+		}
+		if _, ok := done[it.Index]; !ok {
+			done[it.Index] = it
+		}
+	}
+	return done, rows.Err()
+}
+
+// UnfinishedJobs returns jobs left pending or running by a previous run
+func (s *Store) UnfinishedJobs() ([]Job, error) {
+	if s == nil {
+		return nil, nil
+	}
+	rows, err := s.db.Query(`
+		SELECT id, kind, title, chapter, dir, format, quality, state, error, created_at, updated_at
+		FROM jobs WHERE state IN (?, ?) ORDER BY id
+	`, StatePending, StateRunning)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		var job Job
+		if err := rows.Scan(&job.ID, &job.Kind, &job.Title, &job.Chapter, &job.Dir, &job.Format, &job.Quality, &job.State, &job.Error, &job.CreatedAt, &job.UpdatedAt); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// Close closes the database connection
+func (s *Store) Close() {
+	if s != nil && s.db != nil {
+		s.db.Close()
+	}
+}
@@ -0,0 +1,659 @@
+package downloader
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"komiku-scraper/scraper/common"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// hlsSegmentWorkers limits concurrent segment downloads per playlist
+const hlsSegmentWorkers = 4
+
+// IsHLS reports whether a URL or content type points at an HLS playlist
+func IsHLS(mediaURL, contentType string) bool {
+	if u, err := url.Parse(mediaURL); err == nil && strings.HasSuffix(strings.ToLower(u.Path), ".m3u8") {
+		return true
+	}
+	ct := strings.ToLower(contentType)
+	return strings.Contains(ct, "mpegurl")
+}
+
+// DownloadVideo saves mediaURL to dest (without extension), choosing the
+// HLS path for playlists and a ranged, resumable transfer otherwise.
+// quality (e.g. "720p") selects a variant from HLS master playlists.
+// It returns the path of the finished file.
+func (d *Downloader) DownloadVideo(mediaURL, dest, quality string, tracker *Tracker) (string, error) {
+	if err := EnsureDir(filepath.Dir(dest)); err != nil {
+		return "", fmt.Errorf("failed to create directory: %v", err)
+	}
+	if tracker == nil {
+		tracker = NewTracker(nil, 0, 0)
+	}
+
+	if IsHLS(mediaURL, "") {
+		return d.downloadHLS(mediaURL, dest, quality, tracker)
+	}
+
+	// Some hosts serve playlists without the .m3u8 suffix; sniff first
+	hls, contentType, err := d.sniffMedia(mediaURL)
+	if err != nil {
+		return "", err
+	}
+	if hls {
+		return d.downloadHLS(mediaURL, dest, quality, tracker)
+	}
+
+	path := dest + videoExt(mediaURL, contentType)
+	tracker.SetTotal(1)
+	if err := d.downloadRanged(mediaURL, path, tracker); err != nil {
+		return "", err
+	}
+	tracker.ItemDone()
+	return path, nil
+}
+
+// sniffMedia fetches the first bytes of mediaURL and reports whether it is an
+// HLS playlist, by Content-Type or the #EXTM3U header, along with the
+// Content-Type the server sent
+func (d *Downloader) sniffMedia(mediaURL string) (bool, string, error) {
+	resp, err := d.videoRequest(mediaURL, "bytes=0-15")
+	if err != nil {
+		return false, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return false, "", fmt.Errorf("status code %d", resp.StatusCode)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	head := make([]byte, 7)
+	n, _ := io.ReadFull(resp.Body, head)
+	return IsHLS("", contentType) || string(head[:n]) == "#EXTM3U", contentType, nil
+}
+
+// videoRequest issues a GET with an optional Range header
+func (d *Downloader) videoRequest(rawURL, byteRange string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", common.ChromeAndroidUserAgent)
+	if byteRange != "" {
+		req.Header.Set("Range", byteRange)
+	}
+	// Video transfers can run far longer than the image client timeout
+	client := *d.Client
+	client.Timeout = 0
+	return d.do(&client, req)
+}
+
+// downloadRanged downloads into path+".part", resuming from its current size
+// with a Range request, and renames it into place when the transfer is done.
+func (d *Downloader) downloadRanged(rawURL, path string, progress io.Writer) error {
+	if fileExists(path) {
+		return nil
+	}
+	if progress == nil {
+		progress = io.Discard
+	}
+
+	partPath := path + ".part"
+	var lastErr error
+
+	for attempt := 0; attempt < 5; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * 2 * time.Second)
+			log.Printf("[Downloader] Resuming %s (attempt %d): %v", filepath.Base(path), attempt+1, lastErr)
+		}
+
+		done, err := d.rangedAttempt(rawURL, partPath, progress)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if done {
+			return os.Rename(partPath, path)
+		}
+	}
+	return fmt.Errorf("download failed after retries: %v", lastErr)
+}
+
+// rangedAttempt performs one transfer, reporting whether the file is complete
+func (d *Downloader) rangedAttempt(rawURL, partPath string, progress io.Writer) (bool, error) {
+	var offset int64
+	if info, err := os.Stat(partPath); err == nil {
+		offset = info.Size()
+	}
+
+	byteRange := ""
+	if offset > 0 {
+		byteRange = fmt.Sprintf("bytes=%d-", offset)
+	}
+
+	resp, err := d.videoRequest(rawURL, byteRange)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	var total int64 = -1
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		flags |= os.O_APPEND
+		total = parseContentRangeTotal(resp.Header.Get("Content-Range"))
+	case http.StatusOK:
+		// Server ignored the range; start over
+		flags |= os.O_TRUNC
+		offset = 0
+		total = resp.ContentLength
+	case http.StatusRequestedRangeNotSatisfiable:
+		// Only a range past the end of a complete partial file means done;
+		// anything else leaves a .part that can never resume
+		if offset > 0 && parseContentRangeTotal(resp.Header.Get("Content-Range")) == offset {
+			return true, nil
+		}
+		os.Remove(partPath)
+		return false, fmt.Errorf("range not satisfiable at offset %d", offset)
+	default:
+		return false, fmt.Errorf("status code %d", resp.StatusCode)
+	}
+
+	out, err := os.OpenFile(partPath, flags, 0644)
+	if err != nil {
+		return false, err
+	}
+	written, err := io.Copy(io.MultiWriter(out, progress), resp.Body)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if total > 0 && offset+written != total {
+		return false, fmt.Errorf("incomplete transfer: %d of %d bytes", offset+written, total)
+	}
+	return true, nil
+}
+
+// parseContentRangeTotal extracts the total size from "bytes 0-99/1000"
+func parseContentRangeTotal(header string) int64 {
+	idx := strings.LastIndex(header, "/")
+	if idx == -1 {
+		return -1
+	}
+	total, err := strconv.ParseInt(strings.TrimSpace(header[idx+1:]), 10, 64)
+	if err != nil {
+		return -1
+	}
+	return total
+}
+
+// videoExt guesses a container extension from URL path or Content-Type
+func videoExt(rawURL, contentType string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		switch ext := strings.ToLower(filepath.Ext(u.Path)); ext {
+		case ".mp4", ".mkv", ".webm", ".ts", ".mov":
+			return ext
+		}
+	}
+	switch {
+	case strings.Contains(contentType, "matroska"):
+		return ".mkv"
+	case strings.Contains(contentType, "webm"):
+		return ".webm"
+	case strings.Contains(contentType, "mp2t"):
+		return ".ts"
+	}
+	return ".mp4"
+}
+
+// hlsVariant is a stream entry of an HLS master playlist
+type hlsVariant struct {
+	URL       string
+	Height    int
+	Bandwidth int
+}
+
+// hlsKey describes EXT-X-KEY encryption for following segments
+type hlsKey struct {
+	Method string
+	URI    string
+	IV     []byte
+}
+
+// hlsSegment is one media segment of an HLS media playlist
+type hlsSegment struct {
+	Index int
+	URL   string
+	Key   *hlsKey
+}
+
+// hlsPlaylist is a parsed media playlist
+type hlsPlaylist struct {
+	InitURL  string // EXT-X-MAP for fragmented MP4 streams
+	Segments []hlsSegment
+}
+
+// downloadHLS fetches a playlist, picks a variant, downloads segments
+// concurrently and concatenates them into one file. tracker must be non-nil.
+func (d *Downloader) downloadHLS(playlistURL, dest, quality string, tracker *Tracker) (string, error) {
+	body, err := d.fetchText(playlistURL)
+	if err != nil {
+		return "", err
+	}
+
+	// Master playlist: follow the variant closest to the requested quality
+	if variants := parseHLSMaster(body, playlistURL); len(variants) > 0 {
+		v := selectVariant(variants, quality)
+		log.Printf("[Downloader] HLS variant %dp (%d bps) selected", v.Height, v.Bandwidth)
+		playlistURL = v.URL
+		if body, err = d.fetchText(playlistURL); err != nil {
+			return "", err
+		}
+	}
+
+	playlist, err := parseHLSMedia(body, playlistURL)
+	if err != nil {
+		return "", err
+	}
+
+	ext := ".ts"
+	if playlist.InitURL != "" {
+		ext = ".mp4"
+	}
+	finalPath := dest + ext
+	if fileExists(finalPath) {
+		return finalPath, nil
+	}
+
+	// Segments land in a sibling directory so an interrupted download can
+	// pick up where it stopped
+	segDir := dest + ".segments"
+	if err := EnsureDir(segDir); err != nil {
+		return "", err
+	}
+	tracker.SetTotal(len(playlist.Segments))
+
+	keys := &keyCache{d: d, keys: make(map[string][]byte)}
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, hlsSegmentWorkers)
+	errorsChan := make(chan error, len(playlist.Segments))
+
+	for _, seg := range playlist.Segments {
+		wg.Add(1)
+		go func(seg hlsSegment) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			var err error
+			for attempt := 0; attempt < 3; attempt++ {
+				err = d.downloadSegment(seg, segmentPath(segDir, seg.Index), keys, tracker)
+				if err == nil {
+					break
+				}
+				time.Sleep(1 * time.Second)
+			}
+			if err != nil {
+				errorsChan <- fmt.Errorf("segment %d: %v", seg.Index, err)
+				tracker.ItemFailed(err)
+				return
+			}
+			tracker.ItemDone()
+		}(seg)
+	}
+
+	wg.Wait()
+	close(errorsChan)
+	if err, ok := <-errorsChan; ok {
+		return "", fmt.Errorf("hls download incomplete (%d+ failed), first error: %v", len(errorsChan)+1, err)
+	}
+
+	// Concatenate init segment and media segments in playlist order
+	tmpPath := finalPath + ".part"
+	if err := d.concatHLS(tmpPath, playlist, segDir); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	if err := os.Rename(tmpPath, finalPath); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+
+	os.RemoveAll(segDir)
+	return finalPath, nil
+}
+
+// concatHLS writes the init segment, if any, and the downloaded segments to
+// path
+func (d *Downloader) concatHLS(path string, playlist *hlsPlaylist, segDir string) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if playlist.InitURL != "" {
+		if err := d.copyInitSegment(out, playlist.InitURL); err != nil {
+			out.Close()
+			return fmt.Errorf("init segment: %v", err)
+		}
+	}
+	for _, seg := range playlist.Segments {
+		if err := appendFile(out, segmentPath(segDir, seg.Index)); err != nil {
+			out.Close()
+			return err
+		}
+	}
+	return out.Close()
+}
+
+// copyInitSegment writes the EXT-X-MAP segment to out
+func (d *Downloader) copyInitSegment(out io.Writer, rawURL string) error {
+	resp, err := d.videoRequest(rawURL, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status code %d", resp.StatusCode)
+	}
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
+
+// downloadSegment fetches (and decrypts) one segment into path atomically
+func (d *Downloader) downloadSegment(seg hlsSegment, path string, keys *keyCache, progress io.Writer) error {
+	if fileExists(path) {
+		return nil
+	}
+
+	resp, err := d.videoRequest(seg.URL, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status code %d", resp.StatusCode)
+	}
+
+	if progress == nil {
+		progress = io.Discard
+	}
+	data, err := io.ReadAll(io.TeeReader(resp.Body, progress))
+	if err != nil {
+		return err
+	}
+	if resp.ContentLength > 0 && int64(len(data)) != resp.ContentLength {
+		return fmt.Errorf("size mismatch: got %d bytes, expected %d", len(data), resp.ContentLength)
+	}
+
+	if seg.Key != nil {
+		key, err := keys.get(seg.Key.URI)
+		if err != nil {
+			return err
+		}
+		iv := seg.Key.IV
+		if iv == nil {
+			// Default IV is the media sequence number as a big-endian 128-bit int
+			iv = make([]byte, aes.BlockSize)
+			seq := uint64(seg.Index)
+			for i := 0; i < 8; i++ {
+				iv[15-i] = byte(seq >> (8 * i))
+			}
+		}
+		if data, err = decryptAES128(data, key, iv); err != nil {
+			return err
+		}
+	}
+
+	_, err = writeAtomic(path, "", bytes.NewReader(data), nil, -1)
+	return err
+}
+
+// fetchText downloads a small text resource such as a playlist
+func (d *Downloader) fetchText(rawURL string) (string, error) {
+	resp, err := d.videoRequest(rawURL, "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("status code %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	return string(data), err
+}
+
+// parseHLSMaster returns the variants of a master playlist (nil for media playlists)
+func parseHLSMaster(body, baseURL string) []hlsVariant {
+	var variants []hlsVariant
+	var pending *hlsVariant
+
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-STREAM-INF:"):
+			attrs := parseHLSAttributes(strings.TrimPrefix(line, "#EXT-X-STREAM-INF:"))
+			v := hlsVariant{}
+			v.Bandwidth, _ = strconv.Atoi(attrs["BANDWIDTH"])
+			if res := attrs["RESOLUTION"]; res != "" {
+				if parts := strings.SplitN(res, "x", 2); len(parts) == 2 {
+					v.Height, _ = strconv.Atoi(parts[1])
+				}
+			}
+			pending = &v
+		case line != "" && !strings.HasPrefix(line, "#") && pending != nil:
+			pending.URL = resolveURL(baseURL, line)
+			variants = append(variants, *pending)
+			pending = nil
+		}
+	}
+	return variants
+}
+
+// parseHLSMedia parses segments, keys and init map of a media playlist
+func parseHLSMedia(body, baseURL string) (*hlsPlaylist, error) {
+	if !strings.HasPrefix(strings.TrimSpace(body), "#EXTM3U") {
+		return nil, fmt.Errorf("not an HLS playlist")
+	}
+
+	playlist := &hlsPlaylist{}
+	var key *hlsKey
+	sequence := 0
+
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"):
+			sequence, _ = strconv.Atoi(strings.TrimPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"))
+		case strings.HasPrefix(line, "#EXT-X-KEY:"):
+			attrs := parseHLSAttributes(strings.TrimPrefix(line, "#EXT-X-KEY:"))
+			switch attrs["METHOD"] {
+			case "NONE", "":
+				key = nil
+			case "AES-128":
+				key = &hlsKey{Method: "AES-128", URI: resolveURL(baseURL, attrs["URI"])}
+				if iv := strings.TrimPrefix(strings.TrimPrefix(attrs["IV"], "0x"), "0X"); iv != "" {
+					raw, err := hex.DecodeString(iv)
+					if err != nil || len(raw) != aes.BlockSize {
+						return nil, fmt.Errorf("invalid HLS key IV %q", attrs["IV"])
+					}
+					key.IV = raw
+				}
+			default:
+				return nil, fmt.Errorf("unsupported HLS encryption %s", attrs["METHOD"])
+			}
+		case strings.HasPrefix(line, "#EXT-X-MAP:"):
+			attrs := parseHLSAttributes(strings.TrimPrefix(line, "#EXT-X-MAP:"))
+			playlist.InitURL = resolveURL(baseURL, attrs["URI"])
+		case line != "" && !strings.HasPrefix(line, "#"):
+			playlist.Segments = append(playlist.Segments, hlsSegment{
+				Index: sequence,
+				URL:   resolveURL(baseURL, line),
+				Key:   key,
+			})
+			sequence++
+		}
+	}
+
+	if len(playlist.Segments) == 0 {
+		return nil, fmt.Errorf("playlist has no segments")
+	}
+	return playlist, nil
+}
+
+// parseHLSAttributes splits an attribute list, honouring quoted values
+func parseHLSAttributes(s string) map[string]string {
+	attrs := make(map[string]string)
+	for len(s) > 0 {
+		eq := strings.IndexByte(s, '=')
+		if eq == -1 {
+			break
+		}
+		key := strings.TrimSpace(s[:eq])
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, "\"") {
+			end := strings.IndexByte(s[1:], '"')
+			if end == -1 {
+				value, s = s[1:], ""
+			} else {
+				value, s = s[1:end+1], s[end+2:]
+			}
+		} else if comma := strings.IndexByte(s, ','); comma != -1 {
+			value, s = s[:comma], s[comma:]
+		} else {
+			value, s = s, ""
+		}
+		attrs[strings.ToUpper(key)] = value
+		s = strings.TrimPrefix(s, ",")
+	}
+	return attrs
+}
+
+// selectVariant picks the variant matching quality ("720p"), else the best
+// one not above it, else the highest available
+func selectVariant(variants []hlsVariant, quality string) hlsVariant {
+	sorted := append([]hlsVariant(nil), variants...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Height != sorted[j].Height {
+			return sorted[i].Height > sorted[j].Height
+		}
+		return sorted[i].Bandwidth > sorted[j].Bandwidth
+	})
+
+	want := QualityHeight(quality)
+	if want == 0 {
+		return sorted[0]
+	}
+	for _, v := range sorted {
+		if v.Height != 0 && v.Height <= want {
+			return v
+		}
+	}
+	return sorted[0]
+}
+
+// QualityHeight converts labels such as "720p" or "1080" to a pixel height
+func QualityHeight(quality string) int {
+	q := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(quality)), "p")
+	h, _ := strconv.Atoi(q)
+	return h
+}
+
+// resolveURL resolves ref against base
+func resolveURL(base, ref string) string {
+	b, err := url.Parse(base)
+	if err != nil {
+		return ref
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return b.ResolveReference(r).String()
+}
+
+func segmentPath(dir string, index int) string {
+	return filepath.Join(dir, fmt.Sprintf("%06d.ts", index))
+}
+
+func appendFile(out io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(out, f)
+	return err
+}
+
+// keyCache downloads each AES key once per playlist
+type keyCache struct {
+	d    *Downloader
+	mu   sync.Mutex
+	keys map[string][]byte
+}
+
+func (k *keyCache) get(uri string) ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if key, ok := k.keys[uri]; ok {
+		return key, nil
+	}
+	body, err := k.d.fetchText(uri)
+	if err != nil {
+		return nil, fmt.Errorf("fetch key: %v", err)
+	}
+	if len(body) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid AES key length %d", len(body))
+	}
+	k.keys[uri] = []byte(body)
+	return k.keys[uri], nil
+}
+
+// decryptAES128 decrypts an AES-128-CBC segment and strips PKCS#7 padding
+func decryptAES128(data, key, iv []byte) ([]byte, error) {
+	if len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("encrypted segment is not block aligned")
+	}
+	if len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid AES IV length %d", len(iv))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(out, data)
+
+	if n := len(out); n > 0 {
+		pad := int(out[n-1])
+		if pad > 0 && pad <= aes.BlockSize && pad <= n {
+			out = out[:n-pad]
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,120 @@
+package downloader
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseHLSMedia(t *testing.T) {
+	const base = "https://cdn.example.com/v/index.m3u8"
+	iv := bytes.Repeat([]byte{0xab}, 16)
+
+	tests := []struct {
+		name     string
+		body     string
+		wantErr  string
+		initURL  string
+		segments []string
+		indexes  []int
+		keyURI   string
+		keyIV    []byte
+	}{
+		{
+			name:     "plain",
+			body:     "#EXTM3U\n#EXTINF:4,\nseg0.ts\n#EXTINF:4,\n/abs/seg1.ts\n#EXT-X-ENDLIST\n",
+			segments: []string{"https://cdn.example.com/v/seg0.ts", "https://cdn.example.com/abs/seg1.ts"},
+			indexes:  []int{0, 1},
+		},
+		{
+			name:     "media sequence and init map",
+			body:     "#EXTM3U\n#EXT-X-MEDIA-SEQUENCE:7\n#EXT-X-MAP:URI=\"init.mp4\"\n#EXTINF:4,\na.m4s\n#EXTINF:4,\nb.m4s\n",
+			initURL:  "https://cdn.example.com/v/init.mp4",
+			segments: []string{"https://cdn.example.com/v/a.m4s", "https://cdn.example.com/v/b.m4s"},
+			indexes:  []int{7, 8},
+		},
+		{
+			name:     "aes key with iv",
+			body:     "#EXTM3U\n#EXT-X-KEY:METHOD=AES-128,URI=\"key.bin\",IV=0xabababababababababababababababab\n#EXTINF:4,\nseg0.ts\n",
+			segments: []string{"https://cdn.example.com/v/seg0.ts"},
+			indexes:  []int{0},
+			keyURI:   "https://cdn.example.com/v/key.bin",
+			keyIV:    iv,
+		},
+		{
+			name:     "aes key without iv",
+			body:     "#EXTM3U\n#EXT-X-KEY:METHOD=AES-128,URI=\"key.bin\"\n#EXTINF:4,\nseg0.ts\n",
+			segments: []string{"https://cdn.example.com/v/seg0.ts"},
+			indexes:  []int{0},
+			keyURI:   "https://cdn.example.com/v/key.bin",
+		},
+		{
+			name:    "iv not hex",
+			body:    "#EXTM3U\n#EXT-X-KEY:METHOD=AES-128,URI=\"key.bin\",IV=0xnothex\n#EXTINF:4,\nseg0.ts\n",
+			wantErr: "invalid HLS key IV",
+		},
+		{
+			name:    "iv too short",
+			body:    "#EXTM3U\n#EXT-X-KEY:METHOD=AES-128,URI=\"key.bin\",IV=0xabcd\n#EXTINF:4,\nseg0.ts\n",
+			wantErr: "invalid HLS key IV",
+		},
+		{
+			name:    "unsupported method",
+			body:    "#EXTM3U\n#EXT-X-KEY:METHOD=SAMPLE-AES,URI=\"key.bin\"\n#EXTINF:4,\nseg0.ts\n",
+			wantErr: "unsupported HLS encryption",
+		},
+		{
+			name:    "not a playlist",
+			body:    "<html>error</html>",
+			wantErr: "not an HLS playlist",
+		},
+		{
+			name:    "no segments",
+			body:    "#EXTM3U\n#EXT-X-ENDLIST\n",
+			wantErr: "no segments",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHLSMedia(tt.body, base)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.InitURL != tt.initURL {
+				t.Errorf("InitURL = %q, want %q", got.InitURL, tt.initURL)
+			}
+			if len(got.Segments) != len(tt.segments) {
+				t.Fatalf("got %d segments, want %d", len(got.Segments), len(tt.segments))
+			}
+			for i, seg := range got.Segments {
+				if seg.URL != tt.segments[i] || seg.Index != tt.indexes[i] {
+					t.Errorf("segment %d = %s #%d, want %s #%d", i, seg.URL, seg.Index, tt.segments[i], tt.indexes[i])
+				}
+				if tt.keyURI == "" {
+					if seg.Key != nil {
+						t.Errorf("segment %d has unexpected key", i)
+					}
+					continue
+				}
+				if seg.Key == nil || seg.Key.URI != tt.keyURI || !bytes.Equal(seg.Key.IV, tt.keyIV) {
+					t.Errorf("segment %d key = %+v, want %s %x", i, seg.Key, tt.keyURI, tt.keyIV)
+				}
+			}
+		})
+	}
+}
+
+func TestDecryptAES128RejectsBadIV(t *testing.T) {
+	key := bytes.Repeat([]byte{1}, 16)
+	data := make([]byte, 32)
+	if _, err := decryptAES128(data, key, []byte{1, 2, 3}); err == nil {
+		t.Fatal("expected an error for a short IV")
+	}
+}
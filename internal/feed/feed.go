@@ -0,0 +1,348 @@
+// Package feed renders lists of releases as RSS 2.0, Atom and JSON Feed
+package feed
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Output formats, named after the file extension they are served under
+const (
+	FormatRSS  = "xml" // also "rss"
+	FormatAtom = "atom"
+	FormatJSON = "json"
+)
+
+// Feed is a format-independent feed
+type Feed struct {
+	Title       string
+	Link        string // site page the feed mirrors
+	FeedURL     string // where the feed itself is served
+	Description string
+	Updated     time.Time
+	Items       []Item
+}
+
+// Item is one feed entry. ID must stay the same across rebuilds so readers
+// do not show an entry twice.
+type Item struct {
+	ID         string
+	Title      string
+	Link       string
+	Summary    string
+	Image      string
+	Published  time.Time // zero when the source shows no date
+	Categories []string
+}
+
+// Fingerprint hashes the entries of a feed, leaving out its build time and
+// dates derived from it, so it only changes when the entries do
+func (f *Feed) Fingerprint() string {
+	h := sha1.New()
+	io.WriteString(h, f.Title+"\n"+f.FeedURL+"\n")
+	for _, it := range f.Items {
+		io.WriteString(h, it.ID+"\n"+it.Title+"\n"+it.Link+"\n")
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// ContentType returns the media type of a format, "" for unknown formats
+func ContentType(format string) string {
+	switch format {
+	case FormatRSS, "rss":
+		return "application/rss+xml; charset=utf-8"
+	case FormatAtom:
+		return "application/atom+xml; charset=utf-8"
+	case FormatJSON:
+		return "application/feed+json; charset=utf-8"
+	}
+	return ""
+}
+
+// Render encodes f in format
+func Render(f *Feed, format string) ([]byte, error) {
+	switch format {
+	case FormatRSS, "rss":
+		return RSS(f)
+	case FormatAtom:
+		return Atom(f)
+	case FormatJSON:
+		return JSON(f)
+	}
+	return nil, fmt.Errorf("unknown feed format %q", format)
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssEnclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length int    `xml:"length,attr"`
+}
+
+type rssItem struct {
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        rssGUID       `xml:"guid"`
+	Description string        `xml:"description,omitempty"`
+	PubDate     string        `xml:"pubDate,omitempty"`
+	Categories  []string      `xml:"category"`
+	Enclosure   *rssEnclosure `xml:"enclosure"`
+}
+
+type rssAtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssChannel struct {
+	Title         string       `xml:"title"`
+	Link          string       `xml:"link"`
+	Description   string       `xml:"description"`
+	LastBuildDate string       `xml:"lastBuildDate"`
+	AtomLink      *rssAtomLink `xml:"atom:link"`
+	Items         []rssItem    `xml:"item"`
+}
+
+type rssDoc struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	AtomNS  string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+// RSS encodes f as RSS 2.0
+func RSS(f *Feed) ([]byte, error) {
+	ch := rssChannel{
+		Title:         f.Title,
+		Link:          f.Link,
+		Description:   f.Description,
+		LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+	}
+	if f.FeedURL != "" {
+		ch.AtomLink = &rssAtomLink{Href: f.FeedURL, Rel: "self", Type: "application/rss+xml"}
+	}
+	for _, it := range f.Items {
+		item := rssItem{
+			Title:       it.Title,
+			Link:        it.Link,
+			GUID:        rssGUID{IsPermaLink: it.ID == it.Link, Value: it.ID},
+			Description: it.Summary,
+			Categories:  it.Categories,
+		}
+		if !it.Published.IsZero() {
+			item.PubDate = it.Published.UTC().Format(time.RFC1123Z)
+		}
+		if it.Image != "" {
+			item.Enclosure = &rssEnclosure{URL: it.Image, Type: imageType(it.Image)}
+		}
+		ch.Items = append(ch.Items, item)
+	}
+	return marshalXML(rssDoc{Version: "2.0", AtomNS: "http://www.w3.org/2005/Atom", Channel: ch})
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Link       atomLink       `xml:"link"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published,omitempty"`
+	Summary    *atomText      `xml:"summary"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomDoc struct {
+	XMLName  xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string   `xml:"id"`
+	Title    string   `xml:"title"`
+	Subtitle string   `xml:"subtitle,omitempty"`
+	Updated  string   `xml:"updated"`
+	Author   struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// Atom encodes f as Atom 1.0. Entries without a date take the feed's
+// update time, since Atom requires one.
+func Atom(f *Feed) ([]byte, error) {
+	doc := atomDoc{
+		ID:       firstNonEmpty(f.FeedURL, f.Link),
+		Title:    f.Title,
+		Subtitle: f.Description,
+		Updated:  f.Updated.UTC().Format(time.RFC3339),
+		Links:    []atomLink{{Href: f.Link, Rel: "alternate", Type: "text/html"}},
+	}
+	doc.Author.Name = f.Title
+	if f.FeedURL != "" {
+		doc.Links = append(doc.Links, atomLink{Href: f.FeedURL, Rel: "self", Type: "application/atom+xml"})
+	}
+	for _, it := range f.Items {
+		updated := it.Published
+		if updated.IsZero() {
+			updated = f.Updated
+		}
+		entry := atomEntry{
+			ID:      it.ID,
+			Title:   it.Title,
+			Link:    atomLink{Href: it.Link, Rel: "alternate"},
+			Updated: updated.UTC().Format(time.RFC3339),
+		}
+		if !it.Published.IsZero() {
+			entry.Published = entry.Updated
+		}
+		if it.Summary != "" {
+			entry.Summary = &atomText{Type: "text", Value: it.Summary}
+		}
+		for _, c := range it.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: c})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return marshalXML(doc)
+}
+
+type jsonItem struct {
+	ID            string   `json:"id"`
+	URL           string   `json:"url"`
+	Title         string   `json:"title"`
+	ContentText   string   `json:"content_text"`
+	Image         string   `json:"image,omitempty"`
+	DatePublished string   `json:"date_published,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+}
+
+type jsonFeed struct {
+	Version     string     `json:"version"`
+	Title       string     `json:"title"`
+	HomePageURL string     `json:"home_page_url"`
+	FeedURL     string     `json:"feed_url,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Items       []jsonItem `json:"items"`
+}
+
+// JSON encodes f as JSON Feed 1.1
+func JSON(f *Feed) ([]byte, error) {
+	doc := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageURL: f.Link,
+		FeedURL:     f.FeedURL,
+		Description: f.Description,
+		Items:       []jsonItem{},
+	}
+	for _, it := range f.Items {
+		item := jsonItem{
+			ID:          it.ID,
+			URL:         it.Link,
+			Title:       it.Title,
+			ContentText: firstNonEmpty(it.Summary, it.Title),
+			Image:       it.Image,
+			Tags:        it.Categories,
+		}
+		if !it.Published.IsZero() {
+			item.DatePublished = it.Published.UTC().Format(time.RFC3339)
+		}
+		doc.Items = append(doc.Items, item)
+	}
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+var (
+	relativeDate = regexp.MustCompile(`(?i)(\d+)\s+(detik|menit|jam|hari|minggu|bulan|tahun)`)
+	relativeUnit = map[string]time.Duration{
+		"detik":  time.Second,
+		"menit":  time.Minute,
+		"jam":    time.Hour,
+		"hari":   24 * time.Hour,
+		"minggu": 7 * 24 * time.Hour,
+		"bulan":  30 * 24 * time.Hour,
+		"tahun":  365 * 24 * time.Hour,
+	}
+)
+
+// ParseDate reads the dates komiku shows: "12/03/2024", "2024-03-12" or
+// relative ones like "2 jam lalu" and "kemarin", counted back from now.
+// It returns the zero time for anything else.
+func ParseDate(s string, now time.Time) time.Time {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, layout := range []string{"02/01/2006", "2/1/2006", "2006-01-02", "02-01-2006"} {
+		if t, err := time.ParseInLocation(layout, s, jakarta); err == nil {
+			return t
+		}
+	}
+	switch {
+	case strings.Contains(s, "kemarin"):
+		return now.Add(-24 * time.Hour).Truncate(24 * time.Hour)
+	case strings.Contains(s, "baru"):
+		return now.Truncate(time.Minute)
+	}
+	if m := relativeDate.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		// Truncated to the unit so rebuilds within it give the same date
+		unit := relativeUnit[m[2]]
+		return now.Add(-time.Duration(n) * unit).Truncate(unit)
+	}
+	return time.Time{}
+}
+
+// jakarta is the zone the sites print dates in (WIB, UTC+7)
+var jakarta = time.FixedZone("WIB", 7*60*60)
+
+func marshalXML(v any) ([]byte, error) {
+	out, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+func imageType(u string) string {
+	u = strings.ToLower(u)
+	switch {
+	case strings.Contains(u, ".png"):
+		return "image/png"
+	case strings.Contains(u, ".webp"):
+		return "image/webp"
+	case strings.Contains(u, ".gif"):
+		return "image/gif"
+	}
+	return "image/jpeg"
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
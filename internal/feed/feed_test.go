@@ -0,0 +1,50 @@
+package feed
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	base := func() *Feed {
+		return &Feed{
+			Title:   "Komiku - Latest",
+			FeedURL: "https://example.com/feeds/komiku/latest.xml",
+			Updated: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC),
+			Items: []Item{
+				{ID: "komiku:one-piece#chapter-1171", Title: "One Piece - Chapter 1171", Link: "https://komiku.org/one-piece-chapter-1171/"},
+				{ID: "komiku:dandadan#chapter-200", Title: "Dandadan - Chapter 200", Link: "https://komiku.org/dandadan-chapter-200/"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(f *Feed)
+		same   bool
+	}{
+		{"unchanged", func(f *Feed) {}, true},
+		{"build time", func(f *Feed) { f.Updated = f.Updated.Add(time.Hour) }, true},
+		{"item dates", func(f *Feed) { f.Items[0].Published = time.Now() }, true},
+		{"new item", func(f *Feed) {
+			f.Items = append([]Item{{ID: "komiku:one-piece#chapter-1172", Title: "One Piece - Chapter 1172"}}, f.Items...)
+		}, false},
+		{"item removed", func(f *Feed) { f.Items = f.Items[:1] }, false},
+		{"item order", func(f *Feed) { f.Items[0], f.Items[1] = f.Items[1], f.Items[0] }, false},
+		{"item title", func(f *Feed) { f.Items[1].Title = "Dandadan - Chapter 200 (fixed)" }, false},
+		{"item link", func(f *Feed) { f.Items[1].Link = "https://komiku.org/dandadan-chapter-200-2/" }, false},
+		{"feed url", func(f *Feed) { f.FeedURL = "https://example.com/feeds/komiku/latest.json" }, false},
+		{"feed title", func(f *Feed) { f.Title = "Komiku" }, false},
+	}
+
+	want := base().Fingerprint()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := base()
+			tt.change(f)
+			if got := f.Fingerprint(); (got == want) != tt.same {
+				t.Errorf("fingerprint changed = %v, want %v", got != want, !tt.same)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"komiku-scraper/internal/catalog"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CatalogHandler struct {
+	Store *catalog.Store
+}
+
+func NewCatalogHandler(store *catalog.Store) *CatalogHandler {
+	return &CatalogHandler{Store: store}
+}
+
+// Search queries the local catalogue:
+// ?q=&provider=komiku|winbu&genre=a,b&status=&type=&sort=relevance|title|updated&page=&limit=
+func (h *CatalogHandler) Search(c *fiber.Ctx) error {
+	if h.Store == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "Catalogue is not available"})
+	}
+
+	provider := strings.ToLower(c.Query("provider"))
+	if provider != "" && provider != catalog.ProviderKomiku && provider != catalog.ProviderWinbu {
+		return c.Status(400).JSON(fiber.Map{"error": "provider must be komiku or winbu"})
+	}
+	sort := c.Query("sort")
+	if !catalog.ValidSort(sort) {
+		return c.Status(400).JSON(fiber.Map{"error": "sort must be relevance, title or updated"})
+	}
+	page := c.QueryInt("page", 1)
+	if page < 1 {
+		return c.Status(400).JSON(fiber.Map{"error": "page must be 1 or greater"})
+	}
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		return c.Status(400).JSON(fiber.Map{"error": "limit must be between 1 and 100"})
+	}
+
+	query := catalog.Query{
+		Text:     c.Query("q"),
+		Provider: provider,
+		Status:   c.Query("status"),
+		Type:     c.Query("type"),
+		Sort:     sort,
+		Limit:    limit,
+		Offset:   (page - 1) * limit,
+	}
+	for _, g := range strings.Split(c.Query("genre"), ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			query.Genres = append(query.Genres, g)
+		}
+	}
+
+	result, err := h.Store.Search(query)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"items": result.Items,
+		"total": result.Total,
+		"fuzzy": result.Fuzzy,
+		"page":  page,
+		"limit": limit,
+	})
+}
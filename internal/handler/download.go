@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"komiku-scraper/internal/downloader"
+	"komiku-scraper/internal/service"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type DownloadHandler struct {
+	Downloader *downloader.Downloader
+	Komiku     *service.KomikuService
+}
+
+func NewDownloadHandler(dl *downloader.Downloader, komikuSvc *service.KomikuService) *DownloadHandler {
+	return &DownloadHandler{Downloader: dl, Komiku: komikuSvc}
+}
+
+// CreateChapterRequest is the body of POST /downloads/komiku
+type CreateChapterRequest struct {
+	Chapter string `json:"chapter"` // chapter slug, as in /komiku/chapter/:endpoint
+	Manga   string `json:"manga"`
+	Title   string `json:"title"`
+	Format  string `json:"format"`  // optional: jpeg, png or webp
+	Quality int    `json:"quality"` // optional: 1-100 for jpeg/webp
+}
+
+// CreateChapter queues a komiku chapter download and starts it in the background
+func (h *DownloadHandler) CreateChapter(c *fiber.Ctx) error {
+	var req CreateChapterRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.Chapter == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Field 'chapter' is required"})
+	}
+	if req.Manga == "" {
+		req.Manga = "Unknown Manga"
+	}
+	if req.Title == "" {
+		req.Title = req.Chapter
+	}
+
+	transcode := h.Downloader.Transcode
+	if req.Format != "" {
+		format, err := downloader.ParseImageFormat(req.Format)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		transcode = downloader.Transcode{Format: format, Quality: req.Quality}
+	}
+
+	url := "https://komiku.id/ch/" + req.Chapter + "/"
+	images, err := h.Komiku.FetchChapterImages(url)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if len(images) == 0 {
+		return c.Status(404).JSON(fiber.Map{"error": "No images found for chapter"})
+	}
+
+	job, items, err := h.Downloader.EnqueueChapter(req.Manga, req.Title, images, transcode)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	go func() {
+		if err := h.Downloader.RunJob(job, items); err != nil {
+			log.Printf("[Downloader] Job %d failed: %v", job.ID, err)
+		}
+	}()
+
+	return c.Status(202).JSON(fiber.Map{
+		"id":     job.ID,
+		"items":  len(items),
+		"events": fmt.Sprintf("/api/v1/downloads/%d/events", job.ID),
+	})
+}
+
+// Get returns the stored state of a download job
+func (h *DownloadHandler) Get(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid job id"})
+	}
+
+	job, err := h.Downloader.Store.GetJob(int64(id))
+	if err != nil {
+		// Without a queue database only the in-memory progress is known
+		if e, ok := h.Downloader.Bus.Last(int64(id)); ok {
+			return c.JSON(fiber.Map{"job": memoryJob(e), "progress": e})
+		}
+		return c.Status(404).JSON(fiber.Map{"error": "Job not found"})
+	}
+	items, err := h.Downloader.Store.Items(job.ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	resp := fiber.Map{"job": job, "items": items}
+	if e, ok := h.Downloader.Bus.Last(job.ID); ok {
+		resp["progress"] = e
+	} else if job.State == downloader.StateDone || job.State == downloader.StateFailed {
+		resp["progress"] = finishedEvent(h.Downloader.Store, job)
+	}
+	return c.JSON(resp)
+}
+
+// Events streams progress of a download job as Server-Sent Events
+func (h *DownloadHandler) Events(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid job id"})
+	}
+	jobID := int64(id)
+
+	// Subscribe before inspecting state so no terminal event is missed
+	events, cancel := h.Downloader.Bus.Subscribe(jobID)
+
+	var initial *downloader.Event
+	if e, ok := h.Downloader.Bus.Last(jobID); ok {
+		initial = &e
+	} else if job, err := h.Downloader.Store.GetJob(jobID); err == nil {
+		if job.State == downloader.StateDone || job.State == downloader.StateFailed {
+			initial = finishedEvent(h.Downloader.Store, job)
+		}
+	} else {
+		cancel()
+		return c.Status(404).JSON(fiber.Map{"error": "Job not found"})
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // disable nginx buffering
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		if initial != nil {
+			if writeEvent(w, *initial) != nil || initial.Terminal() {
+				return
+			}
+		}
+
+		keepAlive := time.NewTicker(15 * time.Second)
+		defer keepAlive.Stop()
+
+		for {
+			select {
+			case e, ok := <-events:
+				if !ok {
+					return
+				}
+				if writeEvent(w, e) != nil || e.Terminal() {
+					return
+				}
+			case <-keepAlive.C:
+				// Comment lines keep proxies from closing idle streams and
+				// surface client disconnects as write errors
+				fmt.Fprint(w, ": ping\n\n")
+				if w.Flush() != nil {
+					return
+				}
+			}
+		}
+	})
+	return nil
+}
+
+// memoryJob describes a job known only from its progress events
+func memoryJob(e downloader.Event) *downloader.Job {
+	job := &downloader.Job{ID: e.JobID, State: downloader.StateRunning, Error: e.Error, UpdatedAt: e.Time}
+	switch e.Type {
+	case downloader.EventJobDone:
+		job.State = downloader.StateDone
+	case downloader.EventJobFailed:
+		job.State = downloader.StateFailed
+	}
+	return job
+}
+
+// finishedEvent rebuilds the terminal event of a job that is no longer running
+func finishedEvent(store *downloader.Store, job *downloader.Job) *downloader.Event {
+	items, _ := store.Items(job.ID)
+	e := downloader.Event{
+		JobID:      job.ID,
+		Type:       downloader.EventJobDone,
+		ItemsTotal: len(items),
+		Error:      job.Error,
+		Time:       job.UpdatedAt,
+	}
+	if job.State == downloader.StateFailed {
+		e.Type = downloader.EventJobFailed
+	}
+	for _, it := range items {
+		switch it.State {
+		case downloader.StateDone:
+			e.ItemsDone++
+			e.BytesDone += it.Size
+		case downloader.StateFailed:
+			e.ItemsFailed++
+		}
+	}
+	return &e
+}
+
+func writeEvent(w *bufio.Writer, e downloader.Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+	return w.Flush()
+}
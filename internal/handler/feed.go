@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"komiku-scraper/internal/feed"
+	"komiku-scraper/internal/service"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// feedMaxAge matches the home page cache, so readers do not poll faster
+// than the feeds can change
+const feedMaxAge = "public, max-age=900"
+
+type FeedHandler struct {
+	Service *service.FeedService
+}
+
+func NewFeedHandler(s *service.FeedService) *FeedHandler {
+	return &FeedHandler{Service: s}
+}
+
+// Latest serves /feeds/:provider/latest.:format; winbu takes ?kind=anime|movie
+func (h *FeedHandler) Latest(c *fiber.Ctx) error {
+	return h.serve(c, c.Params("format"), func() (*feed.Feed, error) {
+		return h.Service.Latest(c.Params("provider"), strings.ToLower(c.Query("kind")))
+	})
+}
+
+// Series serves /feeds/:provider/series/:file where file is <slug>.<format>
+func (h *FeedHandler) Series(c *fiber.Ctx) error {
+	slug, format := splitFeedFile(c.Params("file"))
+	return h.serve(c, format, func() (*feed.Feed, error) {
+		return h.Service.Series(c.Params("provider"), slug)
+	})
+}
+
+// Genre serves /feeds/:provider/genre/:file where file is <genre>.<format>
+func (h *FeedHandler) Genre(c *fiber.Ctx) error {
+	genre, format := splitFeedFile(c.Params("file"))
+	return h.serve(c, format, func() (*feed.Feed, error) {
+		return h.Service.Genre(c.Params("provider"), strings.ToLower(genre))
+	})
+}
+
+// splitFeedFile splits "dr.stone.json" at its last dot into "dr.stone" and
+// "json". Fiber's ":slug.:format" would split at the first one.
+func splitFeedFile(file string) (name, format string) {
+	dot := strings.LastIndexByte(file, '.')
+	if dot == -1 {
+		return file, ""
+	}
+	return file[:dot], file[dot+1:]
+}
+
+// serve renders a feed in the format named by the path extension, with an
+// ETag so unchanged feeds are answered 304
+func (h *FeedHandler) serve(c *fiber.Ctx, format string, build func() (*feed.Feed, error)) error {
+	format = strings.ToLower(format)
+	contentType := feed.ContentType(format)
+	if contentType == "" {
+		return c.Status(404).JSON(fiber.Map{"error": "Feed format must be .xml (RSS), .atom or .json"})
+	}
+	if _, err := service.OtherProvider(c.Params("provider")); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	f, err := build()
+	if err != nil {
+		if err == service.ErrUnknownProvider || strings.HasPrefix(err.Error(), "kind must") {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	f.FeedURL = c.BaseURL() + c.Path()
+
+	etag := `"` + f.Fingerprint() + `"`
+	c.Set("Cache-Control", feedMaxAge)
+	c.Set("ETag", etag)
+	if c.Get("If-None-Match") == etag {
+		return c.SendStatus(304)
+	}
+
+	body, err := feed.Render(f, format)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	c.Set("Content-Type", contentType)
+	return c.Send(body)
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"komiku-scraper/internal/scheduler"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type JobsHandler struct {
+	Scheduler *scheduler.Scheduler
+}
+
+func NewJobsHandler(s *scheduler.Scheduler) *JobsHandler {
+	return &JobsHandler{Scheduler: s}
+}
+
+// List returns every scheduled job with its last run and next run
+func (h *JobsHandler) List(c *fiber.Ctx) error {
+	return c.JSON(h.Scheduler.Statuses())
+}
+
+// Run queues a job to run now; the route is admin-only
+func (h *JobsHandler) Run(c *fiber.Ctx) error {
+	if err := h.Scheduler.RunNow(c.Params("name")); err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(202).JSON(fiber.Map{"status": "queued"})
+}
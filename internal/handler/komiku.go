@@ -1,8 +1,8 @@
 package handler
 
 import (
-	"fmt"
 	"komiku-scraper/internal/service"
+	"komiku-scraper/scraper/komiku"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
@@ -33,10 +33,7 @@ func (h *KomikuHandler) Search(c *fiber.Ctx) error {
 		return c.Status(400).JSON(fiber.Map{"error": "Query parameter 'q' is required"})
 	}
 
-	// Construct Search URL (using api subdomain with post_type parameter like working exe)
-	searchURL := fmt.Sprintf("https://api.komiku.org/?post_type=manga&s=%s", strings.ReplaceAll(query, " ", "+"))
-
-	results, err := h.Service.FetchAndParseList(searchURL)
+	results, err := h.Service.FetchSearch(query)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -77,3 +74,47 @@ func (h *KomikuHandler) Genres(c *fiber.Ctx) error {
 	}
 	return c.JSON(data)
 }
+
+// Trending returns the ranked list for ?period=daily|weekly|all (default
+// daily) and optional ?type=manga|manhwa|manhua
+func (h *KomikuHandler) Trending(c *fiber.Ctx) error {
+	period := strings.ToLower(c.Query("period", komiku.PeriodDaily))
+	comicType := strings.ToLower(c.Query("type"))
+	if !komiku.ValidRankingPeriod(period) {
+		return c.Status(400).JSON(fiber.Map{"error": "Query parameter 'period' must be daily, weekly or all"})
+	}
+	if !komiku.ValidComicType(comicType) {
+		return c.Status(400).JSON(fiber.Map{"error": "Query parameter 'type' must be manga, manhwa or manhua"})
+	}
+
+	data, err := h.Service.FetchRanking(period, comicType)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(data)
+}
+
+// Browse lists komiku's catalogue with filters:
+// ?type=manhwa&genre=action,fantasy&status=ongoing&sort=popular&page=2
+func (h *KomikuHandler) Browse(c *fiber.Ctx) error {
+	filter := komiku.BrowseFilter{
+		Type:   c.Query("type"),
+		Status: c.Query("status"),
+		Sort:   c.Query("sort"),
+		Page:   c.QueryInt("page", 1),
+	}
+	for _, g := range strings.Split(c.Query("genre"), ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			filter.Genres = append(filter.Genres, g)
+		}
+	}
+	if err := komiku.ValidateBrowseFilter(&filter); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	data, err := h.Service.FetchBrowse(filter)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(data)
+}
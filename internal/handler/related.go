@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"komiku-scraper/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RelatedHandler struct {
+	Service *service.RelatedService
+}
+
+func NewRelatedHandler(svc *service.RelatedService) *RelatedHandler {
+	return &RelatedHandler{Service: svc}
+}
+
+// LinkRequest is the body of POST and DELETE /related/:provider/:slug
+type LinkRequest struct {
+	Provider string `json:"provider"` // the other provider
+	Slug     string `json:"slug"`
+}
+
+// Get returns the adaptations of a work on the other provider: ?refresh=true
+// searches again even when links are stored
+func (h *RelatedHandler) Get(c *fiber.Ctx) error {
+	data, err := h.Service.Related(c.Params("provider"), c.Params("slug"), c.QueryBool("refresh"))
+	if err == service.ErrUnknownProvider {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(data)
+}
+
+// Confirm links a work to one on the other provider
+func (h *RelatedHandler) Confirm(c *fiber.Ctx) error {
+	var req LinkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := h.Service.Confirm(c.Params("provider"), c.Params("slug"), req.Provider, req.Slug); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"status": "linked"})
+}
+
+// Reject marks a suggested or automatic link as wrong
+func (h *RelatedHandler) Reject(c *fiber.Ctx) error {
+	var req LinkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := h.Service.Reject(c.Params("provider"), c.Params("slug"), req.Provider, req.Slug); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"status": "rejected"})
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"komiku-scraper/internal/service"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type SearchHandler struct {
+	Service *service.SearchService
+}
+
+func NewSearchHandler(svc *service.SearchService) *SearchHandler {
+	return &SearchHandler{Service: svc}
+}
+
+// Search queries every provider at once: ?q=&providers=komiku,winbu
+func (h *SearchHandler) Search(c *fiber.Ctx) error {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Query parameter 'q' is required"})
+	}
+	var providers []string
+	for _, p := range strings.Split(c.Query("providers"), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			providers = append(providers, p)
+		}
+	}
+
+	data, err := h.Service.Search(query, providers)
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	// Partial results are still a success; only fail when every provider did
+	for _, p := range data.Providers {
+		if p.OK {
+			return c.JSON(data)
+		}
+	}
+	return c.Status(502).JSON(data)
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"komiku-scraper/internal/service"
+	"komiku-scraper/internal/users"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type UserHandler struct {
+	Users    *users.Store
+	Progress *service.ProgressService
+	Poller   *service.ReleasePoller
+}
+
+func NewUserHandler(store *users.Store, progress *service.ProgressService, poller *service.ReleasePoller) *UserHandler {
+	return &UserHandler{Users: store, Progress: progress, Poller: poller}
+}
+
+// RequireUser authenticates "Authorization: Bearer <token>" and stores the
+// user in c.Locals("user")
+func (h *UserHandler) RequireUser(c *fiber.Ctx) error {
+	if h.Users == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "User accounts are not available"})
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(c.Get("Authorization"), "Bearer "))
+	user, err := h.Users.Authenticate(token)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if user == nil {
+		return c.Status(401).JSON(fiber.Map{"error": "Valid bearer token required"})
+	}
+	c.Locals("user", user)
+	return c.Next()
+}
+
+func currentUser(c *fiber.Ctx) *users.User {
+	return c.Locals("user").(*users.User)
+}
+
+// Register creates a user and returns its first token, which is not shown again
+func (h *UserHandler) Register(c *fiber.Ctx) error {
+	if h.Users == nil {
+		return c.Status(503).JSON(fiber.Map{"error": "User accounts are not available"})
+	}
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Field 'name' is required"})
+	}
+
+	user, token, err := h.Users.CreateUser(req.Name)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(fiber.Map{"user": user, "token": token})
+}
+
+// Me returns the authenticated user
+func (h *UserHandler) Me(c *fiber.Ctx) error {
+	return c.JSON(currentUser(c))
+}
+
+// CreateToken issues another token for the authenticated user
+func (h *UserHandler) CreateToken(c *fiber.Ctx) error {
+	token, err := h.Users.CreateToken(currentUser(c).ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(fiber.Map{"token": token})
+}
+
+// RevokeToken deletes the token the request was made with
+func (h *UserHandler) RevokeToken(c *fiber.Ctx) error {
+	token := strings.TrimSpace(strings.TrimPrefix(c.Get("Authorization"), "Bearer "))
+	if err := h.Users.RevokeToken(token); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(204)
+}
+
+// SaveProgress records a resume position; "completed": true also adds the
+// chapter/episode to the history
+func (h *UserHandler) SaveProgress(c *fiber.Ctx) error {
+	var in service.ProgressInput
+	if err := c.BodyParser(&in); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if err := h.Progress.Record(currentUser(c).ID, in); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"status": "saved"})
+}
+
+// ListProgress returns resume positions: ?provider=&series=
+func (h *UserHandler) ListProgress(c *fiber.Ctx) error {
+	list, err := h.Users.Progress(currentUser(c).ID, c.Query("provider"), c.Query("series"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(list)
+}
+
+// History returns finished chapters and episodes: ?page=&limit=
+func (h *UserHandler) History(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 50)
+	if page < 1 || limit < 1 || limit > 200 {
+		return c.Status(400).JSON(fiber.Map{"error": "page must be 1 or greater and limit between 1 and 200"})
+	}
+	events, err := h.Users.History(currentUser(c).ID, limit, (page-1)*limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(events)
+}
+
+// Continue returns the "continue reading/watching" list: ?provider=&limit=
+func (h *UserHandler) Continue(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 50 {
+		return c.Status(400).JSON(fiber.Map{"error": "limit must be between 1 and 50"})
+	}
+	items, err := h.Progress.Continue(currentUser(c).ID, c.Query("provider"), limit)
+	if err == service.ErrUnknownProvider {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(items)
+}
+
+// Follow adds a series to the follow list. The first follow of a series
+// takes its snapshot, so only chapters/episodes released afterwards are
+// reported.
+func (h *UserHandler) Follow(c *fiber.Ctx) error {
+	var req struct {
+		Provider string `json:"provider"`
+		Series   string `json:"series"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if _, err := service.OtherProvider(req.Provider); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+	if req.Series == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Field 'series' is required"})
+	}
+
+	snap, err := h.Users.Snapshot(req.Provider, req.Series)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if snap == nil {
+		// Also proves the series exists before it is stored
+		if _, err := h.Poller.CheckNow(req.Provider, req.Series); err != nil {
+			if err == service.ErrSeriesNotFound {
+				return c.Status(404).JSON(fiber.Map{"error": "Series not found"})
+			}
+			return c.Status(502).JSON(fiber.Map{"error": "Could not load series: " + err.Error()})
+		}
+	}
+	if err := h.Users.Follow(currentUser(c).ID, req.Provider, req.Series); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(fiber.Map{"status": "following"})
+}
+
+// ListFollows returns the followed series
+func (h *UserHandler) ListFollows(c *fiber.Ctx) error {
+	follows, err := h.Users.Follows(currentUser(c).ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(follows)
+}
+
+// Unfollow removes a series from the follow list
+func (h *UserHandler) Unfollow(c *fiber.Ctx) error {
+	if err := h.Users.Unfollow(currentUser(c).ID, c.Params("provider"), c.Params("series")); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(204)
+}
+
+// Releases returns new chapters/episodes of followed series: ?since=&limit=
+// where since is an RFC 3339 time
+func (h *UserHandler) Releases(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 50)
+	if limit < 1 || limit > 200 {
+		return c.Status(400).JSON(fiber.Map{"error": "limit must be between 1 and 200"})
+	}
+	var since time.Time
+	if v := c.Query("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "since must be an RFC 3339 time, e.g. 2024-01-02T15:04:05Z"})
+		}
+		since = t
+	}
+	releases, err := h.Users.Releases(currentUser(c).ID, since, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(releases)
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"komiku-scraper/internal/notify"
+	"komiku-scraper/internal/service"
+	"komiku-scraper/internal/users"
+	"log"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WebhookHandler struct {
+	Users      *users.Store
+	Dispatcher *notify.Dispatcher
+}
+
+func NewWebhookHandler(store *users.Store, dispatcher *notify.Dispatcher) *WebhookHandler {
+	return &WebhookHandler{Users: store, Dispatcher: dispatcher}
+}
+
+// Create subscribes a URL to new releases of followed series
+func (h *WebhookHandler) Create(c *fiber.Ctx) error {
+	var req struct {
+		URL      string `json:"url"`
+		Secret   string `json:"secret"`
+		Sink     string `json:"sink"`
+		ChatID   string `json:"chat_id"`
+		Provider string `json:"provider"`
+		Series   string `json:"series"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Field 'url' must be an http(s) URL"})
+	}
+	// Catch the obvious internal targets early; names are checked again
+	// when deliveries dial
+	if ip := net.ParseIP(u.Hostname()); (ip != nil && !notify.PublicIP(ip)) || strings.EqualFold(u.Hostname(), "localhost") {
+		if !h.Dispatcher.AllowPrivate {
+			return c.Status(400).JSON(fiber.Map{"error": "Field 'url' must point at a public host"})
+		}
+	}
+	if req.Sink == "" {
+		req.Sink = users.SinkWebhook
+	}
+	if _, ok := notify.Sinks[req.Sink]; !ok {
+		return c.Status(400).JSON(fiber.Map{"error": "Field 'sink' must be webhook, discord or telegram"})
+	}
+	if req.Sink == users.SinkTelegram && strings.TrimSpace(req.ChatID) == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Field 'chat_id' is required for telegram"})
+	}
+	if req.Provider != "" {
+		if _, err := service.OtherProvider(req.Provider); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	w, err := h.Users.CreateWebhook(users.Webhook{
+		UserID:   currentUser(c).ID,
+		URL:      req.URL,
+		Secret:   req.Secret,
+		Sink:     req.Sink,
+		ChatID:   strings.TrimSpace(req.ChatID),
+		Provider: req.Provider,
+		Series:   req.Series,
+	})
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(201).JSON(w)
+}
+
+// List returns the user's webhooks; secrets are never returned
+func (h *WebhookHandler) List(c *fiber.Ctx) error {
+	hooks, err := h.Users.Webhooks(currentUser(c).ID)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(hooks)
+}
+
+// Delete removes a webhook and its dead letters
+func (h *WebhookHandler) Delete(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid webhook id"})
+	}
+	if err := h.Users.DeleteWebhook(currentUser(c).ID, int64(id)); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.SendStatus(204)
+}
+
+// Test sends a sample event once and reports whether the receiver took it
+func (h *WebhookHandler) Test(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "Invalid webhook id"})
+	}
+	w, err := h.Users.Webhook(currentUser(c).ID, int64(id))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	if w == nil {
+		return c.Status(404).JSON(fiber.Map{"error": "Webhook not found"})
+	}
+	if err := h.Dispatcher.Test(*w); err != nil {
+		// The receiver's answer stays in the log: echoing it would turn this
+		// endpoint into a probe of whatever the URL points at
+		log.Printf("[Notify] Test delivery to webhook %d failed: %v", w.ID, err)
+		return c.Status(502).JSON(fiber.Map{"error": "Delivery failed"})
+	}
+	return c.JSON(fiber.Map{"status": "delivered"})
+}
+
+// DeadLetters lists deliveries that failed every attempt: ?limit=
+func (h *WebhookHandler) DeadLetters(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 50)
+	if limit < 1 || limit > 200 {
+		return c.Status(400).JSON(fiber.Map{"error": "limit must be between 1 and 200"})
+	}
+	letters, err := h.Users.DeadLetters(currentUser(c).ID, limit)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(letters)
+}
@@ -1,7 +1,12 @@
 package handler
 
 import (
+	"encoding/base64"
 	"komiku-scraper/internal/service"
+	"komiku-scraper/scraper/winbu"
+	"log"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -118,5 +123,116 @@ func (h *WinbuHandler) Episode(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
+
+	// ?resolve=true follows every server to its embed host and returns
+	// direct MP4/HLS sources (slower: one extra round-trip per server)
+	if c.QueryBool("resolve") {
+		data = h.Service.ResolveEpisodeMedia(data)
+	}
+	// ?resolve_downloads=true follows download links through shorteners
+	// to the final file host
+	if c.QueryBool("resolve_downloads") {
+		data = h.Service.ResolveEpisodeDownloads(data)
+	}
 	return c.JSON(data)
 }
+
+// Subtitle serves an embed host's SRT/ASS/VTT track as WebVTT so browsers can
+// load it in a <track> element. url is a track URL from the Subtitles of a
+// resolved episode, base64 (URL-safe) encoded; other URLs are refused.
+func (h *WinbuHandler) Subtitle(c *fiber.Ctx) error {
+	encoded := c.Query("url")
+	if encoded == "" {
+		return c.Status(400).JSON(fiber.Map{"error": "Query parameter 'url' is required"})
+	}
+	raw, err := base64.URLEncoding.DecodeString(encoded)
+	if err != nil {
+		if raw, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "Invalid base64 URL"})
+		}
+	}
+
+	vtt, err := h.Service.FetchSubtitle(string(raw))
+	if err == service.ErrUnknownSubtitle {
+		return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(502).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	c.Set("Content-Type", "text/vtt; charset=utf-8")
+	c.Set("Cache-Control", "public, max-age=3600")
+	return c.Send(vtt)
+}
+
+// Schedule returns the airing schedule grouped by weekday. ?day=today (WIB)
+// or ?day=monday / ?day=senin narrows it to one day.
+func (h *WinbuHandler) Schedule(c *fiber.Ctx) error {
+	days, err := h.Service.FetchSchedule()
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	day := strings.ToLower(c.Query("day"))
+	if day == "" {
+		return c.JSON(days)
+	}
+
+	var weekday time.Weekday
+	if day == "today" {
+		weekday = time.Now().In(winbu.WIB).Weekday()
+	} else if wd, ok := winbu.ParseWeekday(day); ok {
+		weekday = wd
+	} else {
+		return c.Status(400).JSON(fiber.Map{"error": "Query parameter 'day' must be today or a weekday name"})
+	}
+
+	for _, d := range days {
+		if d.Day == strings.ToLower(weekday.String()) {
+			return c.JSON(d)
+		}
+	}
+	return c.JSON(winbu.ScheduleDay{Day: strings.ToLower(weekday.String()), Items: []winbu.ScheduleItem{}})
+}
+
+// Index lists one page of the archive:
+// ?kind=anime|film&letter=A|year=2024|status=ongoing&page=2
+func (h *WinbuHandler) Index(c *fiber.Ctx) error {
+	filter := winbu.IndexFilter{
+		Kind:   c.Query("kind"),
+		Letter: c.Query("letter"),
+		Year:   c.QueryInt("year"),
+		Status: c.Query("status"),
+		Page:   c.QueryInt("page", 1),
+	}
+	if err := winbu.ValidateIndexFilter(&filter); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	data, err := h.Service.FetchIndex(filter)
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(data)
+}
+
+// Catalogue returns every winbu series and movie from the last index crawl.
+// Without a cached crawl (or with ?refresh=true, admin only, see routes) a
+// crawl is started in the background and 202 is returned; poll again once it
+// finishes.
+func (h *WinbuHandler) Catalogue(c *fiber.Ctx) error {
+	if !c.QueryBool("refresh") {
+		if data, ok := h.Service.CachedCatalogue(); ok {
+			return c.JSON(data)
+		}
+	}
+
+	if !h.Service.Crawling() {
+		go func() {
+			if _, err := h.Service.CrawlCatalogue(); err != nil {
+				log.Printf("[Winbu] Catalogue crawl failed: %v", err)
+			}
+		}()
+	}
+	return c.Status(202).JSON(fiber.Map{"status": "crawling"})
+}
@@ -0,0 +1,195 @@
+// Package notify delivers new-release events to user webhooks
+package notify
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"komiku-scraper/internal/users"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// EventRelease is the type of events sent for new chapters and episodes
+const EventRelease = "release.new"
+
+// Event is the body posted to generic webhooks
+type Event struct {
+	ID        string        `json:"id"` // also sent as X-Webhook-Delivery
+	Type      string        `json:"type"`
+	CreatedAt time.Time     `json:"created_at"`
+	Release   users.Release `json:"release"`
+}
+
+// Dispatcher posts events to webhooks, retrying failed deliveries with
+// exponential backoff and recording the ones that never succeed as dead
+// letters
+type Dispatcher struct {
+	Users    *users.Store
+	Client   *http.Client
+	Attempts int           // deliveries tried per event and webhook
+	Backoff  time.Duration // wait before the first retry, doubled after each
+
+	// AllowPrivate lets webhooks target non-public addresses; Client must
+	// have been built with the same setting
+	AllowPrivate bool
+}
+
+// NewDispatcher reads WEBHOOK_ATTEMPTS (default 5) and
+// WEBHOOK_ALLOW_PRIVATE, which lets webhooks reach private and loopback
+// addresses for local development
+func NewDispatcher(store *users.Store) *Dispatcher {
+	attempts := 5
+	if v, err := strconv.Atoi(os.Getenv("WEBHOOK_ATTEMPTS")); err == nil && v > 0 {
+		attempts = v
+	}
+	allowPrivate, _ := strconv.ParseBool(os.Getenv("WEBHOOK_ALLOW_PRIVATE"))
+	return &Dispatcher{
+		Users:        store,
+		Client:       NewClient(allowPrivate),
+		Attempts:     attempts,
+		Backoff:      5 * time.Second,
+		AllowPrivate: allowPrivate,
+	}
+}
+
+// Dispatch notifies the matching webhooks of each release. Deliveries run
+// in the background; it returns once they are started.
+func (d *Dispatcher) Dispatch(releases []users.Release) {
+	for _, r := range releases {
+		hooks, err := d.Users.WebhooksFor(r)
+		if err != nil {
+			log.Printf("[Notify] Failed to look up webhooks for %s/%s: %v", r.Provider, r.Series, err)
+			continue
+		}
+		for _, w := range hooks {
+			go d.deliver(w, NewEvent(r))
+		}
+	}
+}
+
+// Test sends one sample event to a webhook without retrying and returns the
+// delivery error, if any
+func (d *Dispatcher) Test(w users.Webhook) error {
+	e := NewEvent(users.Release{
+		Provider:    w.Provider,
+		Series:      w.Series,
+		SeriesTitle: "Test Series",
+		Item:        "test-item",
+		ItemTitle:   "Test notification",
+		DetectedAt:  time.Now(),
+	})
+	if e.Release.Provider == "" {
+		e.Release.Provider = "komiku"
+	}
+	body, err := payload(w, e)
+	if err != nil {
+		return err
+	}
+	_, err = d.send(w, e, body)
+	return err
+}
+
+func NewEvent(r users.Release) Event {
+	raw := make([]byte, 8)
+	rand.Read(raw)
+	return Event{ID: hex.EncodeToString(raw), Type: EventRelease, CreatedAt: time.Now(), Release: r}
+}
+
+// deliver posts an event until it is accepted, the receiver rejects it for
+// good (a 4xx other than 408/429) or Attempts run out
+func (d *Dispatcher) deliver(w users.Webhook, e Event) {
+	body, err := payload(w, e)
+	if err != nil {
+		d.deadLetter(w, e, body, 0, err)
+		return
+	}
+
+	wait := d.Backoff
+	for attempt := 1; ; attempt++ {
+		retry, err := d.send(w, e, body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= d.Attempts {
+			d.deadLetter(w, e, body, attempt, err)
+			return
+		}
+		log.Printf("[Notify] Webhook %d delivery %s failed (attempt %d), retrying in %s: %v", w.ID, e.ID, attempt, wait, err)
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+// send makes one delivery and reports whether a failure is worth retrying
+func (d *Dispatcher) send(w users.Webhook, e Event, body []byte) (bool, error) {
+	req, err := http.NewRequest("POST", w.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "komiku-scraper-webhooks/1.0")
+	req.Header.Set("X-Webhook-Event", e.Type)
+	req.Header.Set("X-Webhook-Delivery", e.ID)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	if w.Secret != "" {
+		req.Header.Set("X-Webhook-Signature", "sha256="+Sign(w.Secret, timestamp, body))
+	}
+
+	resp, err := d.Client.Do(req)
+	if errors.Is(err, ErrBlockedAddress) {
+		return false, ErrBlockedAddress
+	}
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout
+	return retry, fmt.Errorf("receiver answered %s", resp.Status)
+}
+
+func (d *Dispatcher) deadLetter(w users.Webhook, e Event, body []byte, attempts int, cause error) {
+	log.Printf("[Notify] Webhook %d delivery %s failed after %d attempts: %v", w.ID, e.ID, attempts, cause)
+	err := d.Users.SaveDeadLetter(users.DeadLetter{
+		WebhookID: w.ID,
+		Event:     e.Type,
+		Payload:   string(body),
+		Attempts:  attempts,
+		LastError: cause.Error(),
+	})
+	if err != nil {
+		log.Printf("[Notify] Failed to store dead letter for webhook %d: %v", w.ID, err)
+	}
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>" under secret,
+// sent as "X-Webhook-Signature: sha256=<hex>"
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func payload(w users.Webhook, e Event) ([]byte, error) {
+	sink, ok := Sinks[w.Sink]
+	if !ok {
+		return nil, fmt.Errorf("unknown sink %q", w.Sink)
+	}
+	return sink.Payload(w, e)
+}
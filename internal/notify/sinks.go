@@ -0,0 +1,89 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"komiku-scraper/internal/catalog"
+	"komiku-scraper/internal/users"
+)
+
+// Sink turns an event into the request body a kind of receiver expects
+type Sink interface {
+	Payload(w users.Webhook, e Event) ([]byte, error)
+}
+
+// Sinks maps users.Sink* kinds to their payload builders
+var Sinks = map[string]Sink{
+	users.SinkWebhook:  webhookSink{},
+	users.SinkDiscord:  discordSink{},
+	users.SinkTelegram: telegramSink{},
+}
+
+// webhookSink posts the event itself
+type webhookSink struct{}
+
+func (webhookSink) Payload(_ users.Webhook, e Event) ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// discordSink posts a message with one embed linking to the release, in the
+// shape of Discord's "Execute Webhook" endpoint
+type discordSink struct{}
+
+func (discordSink) Payload(_ users.Webhook, e Event) ([]byte, error) {
+	r := e.Release
+	type embed struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		URL         string `json:"url,omitempty"`
+		Timestamp   string `json:"timestamp"`
+	}
+	return json.Marshal(struct {
+		Content string  `json:"content"`
+		Embeds  []embed `json:"embeds"`
+	}{
+		Content: message(r),
+		Embeds: []embed{{
+			Title:       seriesName(r),
+			Description: r.ItemTitle,
+			URL:         releaseURL(r),
+			Timestamp:   r.DetectedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		}},
+	})
+}
+
+// telegramSink posts a Bot API sendMessage body; the webhook URL is
+// https://api.telegram.org/bot<token>/sendMessage
+type telegramSink struct{}
+
+func (telegramSink) Payload(w users.Webhook, e Event) ([]byte, error) {
+	if w.ChatID == "" {
+		return nil, fmt.Errorf("telegram webhooks need a chat_id")
+	}
+	text := message(e.Release)
+	if link := releaseURL(e.Release); link != "" {
+		text += "\n" + link
+	}
+	return json.Marshal(map[string]string{"chat_id": w.ChatID, "text": text})
+}
+
+// message is the one-line notification text of a release
+func message(r users.Release) string {
+	item := r.ItemTitle
+	if item == "" {
+		item = r.Item
+	}
+	return fmt.Sprintf("New on %s: %s - %s", r.Provider, seriesName(r), item)
+}
+
+func seriesName(r users.Release) string {
+	if r.SeriesTitle != "" {
+		return r.SeriesTitle
+	}
+	return r.Series
+}
+
+// releaseURL makes a release's endpoint absolute on its provider's site
+func releaseURL(r users.Release) string {
+	return catalog.AbsoluteURL(r.Provider, r.Endpoint)
+}
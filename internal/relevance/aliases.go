@@ -0,0 +1,59 @@
+package relevance
+
+// aliases groups the names one show is searched by: the romanized Japanese
+// title komiku and winbu usually list, and the English title people type.
+// Every name in a group is normalized with Fold before comparing.
+var aliases = [][]string{
+	{"shingeki no kyojin", "attack on titan"},
+	{"kimetsu no yaiba", "demon slayer"},
+	{"boku no hero academia", "my hero academia"},
+	{"jujutsu kaisen", "sorcery fight"},
+	{"tate no yuusha no nariagari", "the rising of the shield hero", "shield hero"},
+	{"tensei shitara slime datta ken", "that time i got reincarnated as a slime", "tensura"},
+	{"re zero kara hajimeru isekai seikatsu", "re zero starting life in another world", "rezero"},
+	{"kage no jitsuryokusha ni naritakute", "the eminence in shadow"},
+	{"sousou no frieren", "frieren beyond journey s end", "frieren"},
+	{"ore dake level up na ken", "solo leveling"},
+	{"yakusoku no neverland", "the promised neverland"},
+	{"kaguya sama wa kokurasetai", "kaguya sama love is war"},
+	{"mushoku tensei", "jobless reincarnation"},
+	{"dungeon meshi", "delicious in dungeon"},
+	{"kusuriya no hitorigoto", "the apothecary diaries"},
+	{"ore no imouto ga konna ni kawaii wake ga nai", "oreimo"},
+	{"yahari ore no seishun love comedy wa machigatteiru", "oregairu", "my teen romantic comedy snafu"},
+	{"kono subarashii sekai ni shukufuku wo", "konosuba"},
+	{"shigatsu wa kimi no uso", "your lie in april"},
+	{"kimi no na wa", "your name"},
+	{"sen to chihiro no kamikakushi", "spirited away"},
+	{"nanatsu no taizai", "the seven deadly sins"},
+	{"boku dake ga inai machi", "erased"},
+	{"koe no katachi", "a silent voice"},
+	{"na honjaman level up", "solo leveling"},
+}
+
+// aliasIndex maps each folded name to its group
+var aliasIndex = func() map[string][]string {
+	index := make(map[string][]string)
+	for _, group := range aliases {
+		for _, name := range group {
+			key := Fold(name)
+			index[key] = append(index[key], group...)
+		}
+	}
+	return index
+}()
+
+// Variants returns query followed by the other names of the show it names,
+// if it is a known alias: "Attack on Titan" also gives "shingeki no kyojin"
+func Variants(query string) []string {
+	variants := []string{query}
+	folded := Fold(CleanQuery(query))
+	seen := map[string]bool{Fold(query): true}
+	for _, name := range aliasIndex[folded] {
+		if key := Fold(name); !seen[key] {
+			seen[key] = true
+			variants = append(variants, name)
+		}
+	}
+	return variants
+}
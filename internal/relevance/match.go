@@ -0,0 +1,83 @@
+package relevance
+
+import (
+	"regexp"
+	"strings"
+)
+
+// installmentPattern matches the season/part/movie markers that separate an
+// anime's entries but not its source manga: "Season 3", "2nd Season",
+// "Part 2", "S2", "The Movie", "Final Season"
+var installmentPattern = regexp.MustCompile(`\b(?:(?:the )?final season|season \d+|\d+(?:st|nd|rd|th) season|part \d+|cour \d+|s\d+|(?:the )?movie(?: \d+)?|film)\b`)
+
+// subtitleSeparator splits "Demon Slayer: Kimetsu no Yaiba" into the names
+// it combines
+var subtitleSeparator = regexp.MustCompile(`\s*(?::|\s-\s)\s*`)
+
+// names returns the base forms of titles plus those of their colon/dash
+// separated parts of at least two words; single words like the "Re" of
+// "Re:Zero" would match far too much
+func names(titles []string) []string {
+	var result []string
+	for _, title := range titles {
+		if t := baseTitle(title); t != "" {
+			result = append(result, t)
+		}
+		parts := subtitleSeparator.Split(title, -1)
+		if len(parts) < 2 {
+			continue
+		}
+		for _, part := range parts {
+			if t := baseTitle(part); len(strings.Fields(t)) >= 2 {
+				result = append(result, t)
+			}
+		}
+	}
+	return result
+}
+
+// baseTitle folds a title and drops installment markers and listing noise
+func baseTitle(title string) string {
+	t := cleanTitle(Fold(title))
+	t = installmentPattern.ReplaceAllString(t, " ")
+	return Normalize(t)
+}
+
+// Match rates how likely two works with the given titles (main and
+// alternative) are the same story, from 0 to 1. Unlike Score it is symmetric
+// and ignores season and part markers, so "Shingeki no Kyojin Season 3"
+// matches the manga "Shingeki no Kyojin" and, through the alias table,
+// "Attack on Titan".
+func Match(a, b []string) float64 {
+	best := 0.0
+	namesB := names(b)
+	for _, x := range names(a) {
+		for _, y := range namesB {
+			best = max(best, matchPair(x, y))
+			if best == 1 {
+				return best
+			}
+		}
+	}
+	return best
+}
+
+func matchPair(x, y string) float64 {
+	if x == y {
+		return 1
+	}
+	for _, name := range aliasIndex[x] {
+		if Fold(name) == y {
+			return 0.95
+		}
+	}
+	// Symmetric Dice only: containment would make "One" match "One Piece"
+	gx, gy := Trigrams(x), Trigrams(y)
+	shared := 0
+	for g := range gx {
+		if gy[g] {
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(gx)+len(gy))
+}
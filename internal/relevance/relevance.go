@@ -0,0 +1,190 @@
+package relevance
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// diacritics folds the accented letters found in romanized Japanese and
+// European titles; macrons mark long vowels ("Shōnen" is also "Shounen")
+var diacritics = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a",
+	'ç': "c",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i",
+	'ñ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u",
+	'ý': "y", 'ÿ': "y",
+	'ß': "ss", 'æ': "ae", 'œ': "oe",
+}
+
+// Normalize lower-cases s, folds diacritics and replaces punctuation with
+// single spaces, so "Re:Zero - Kara" and "re zero kara" compare equal
+func Normalize(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		folded, isDiacritic := diacritics[r]
+		if !isDiacritic && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			space = true
+			continue
+		}
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		if isDiacritic {
+			b.WriteString(folded)
+		} else {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// longVowels collapses the competing romanizations of Japanese long vowels:
+// "shoujo" and "shojo", or "yuusha" and "yusha"
+var longVowels = strings.NewReplacer("ou", "o", "oo", "o", "uu", "u", "aa", "a", "ei", "e")
+
+// Fold normalizes s and collapses romanization variants. Use it on both sides
+// of a comparison, never for display or as a site query.
+func Fold(s string) string {
+	return longVowels.Replace(Normalize(s))
+}
+
+// noiseWords are Indonesian and English words people add to a search that
+// never appear in titles as listed: "nonton one piece sub indo"
+var noiseWords = map[string]bool{
+	"nonton": true, "baca": true, "komik": true, "download": true, "streaming": true, "stream": true,
+	"sub": true, "indo": true, "subtitle": true, "indonesia": true, "bahasa": true,
+	"gratis": true, "lengkap": true, "terbaru": true, "online": true,
+}
+
+// CleanQuery trims a user query for the sites' search: collapses whitespace
+// and drops noise words, unless nothing would be left
+func CleanQuery(query string) string {
+	words := strings.Fields(query)
+	var kept []string
+	for _, w := range words {
+		if !noiseWords[Normalize(w)] {
+			kept = append(kept, w)
+		}
+	}
+	if len(kept) == 0 {
+		return strings.Join(words, " ")
+	}
+	return strings.Join(kept, " ")
+}
+
+// titleNoise matches the suffixes winbu appends to listings and the prefix
+// komiku puts in its headings ("Komik One Piece"), stripped before scoring so
+// they do not count against a match
+var (
+	titleNoise       = []string{"subtitle indonesia", "sub indo", "batch", "bd"}
+	titleNoisePrefix = []string{"komik", "nonton"}
+)
+
+func cleanTitle(folded string) string {
+	for _, suffix := range titleNoise {
+		folded = strings.TrimSuffix(folded, " "+Fold(suffix))
+	}
+	for _, prefix := range titleNoisePrefix {
+		folded = strings.TrimPrefix(folded, Fold(prefix)+" ")
+	}
+	return folded
+}
+
+// Score rates how well one of titles matches query, from 0 to 1: an exact
+// match scores 1, a title starting with the query 0.9, a title containing
+// every query word (as word prefixes) 0.8, otherwise trigram similarity
+// scaled below that. Aliases of the query (see Variants) count as the query.
+func Score(query string, titles ...string) float64 {
+	best := 0.0
+	for _, q := range Variants(CleanQuery(query)) {
+		q = Fold(q)
+		if q == "" {
+			continue
+		}
+		qGrams := Trigrams(q)
+		for _, title := range titles {
+			t := cleanTitle(Fold(title))
+			if t == "" {
+				continue
+			}
+			best = max(best, score(q, t, qGrams))
+		}
+	}
+	return best
+}
+
+func score(q, t string, qGrams map[string]bool) float64 {
+	switch {
+	case t == q:
+		return 1
+	case strings.HasPrefix(t, q):
+		return 0.9
+	case containsWords(t, q):
+		return 0.8
+	}
+	return 0.7 * Similarity(qGrams, Trigrams(t))
+}
+
+// containsWords reports whether every word of q starts a word of t
+func containsWords(t, q string) bool {
+	t = " " + t
+	for _, w := range strings.Fields(q) {
+		if !strings.Contains(t, " "+w) {
+			return false
+		}
+	}
+	return true
+}
+
+// Rank returns items sorted by Score against query, best first. Items scoring
+// the same keep the order the site returned them in.
+func Rank[T any](items []T, query string, titles func(T) []string) []T {
+	scores := make([]float64, len(items))
+	order := make([]int, len(items))
+	for i, item := range items {
+		scores[i] = Score(query, titles(item)...)
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return scores[order[a]] > scores[order[b]] })
+
+	ranked := make([]T, len(items))
+	for i, idx := range order {
+		ranked[i] = items[idx]
+	}
+	return ranked
+}
+
+// Trigrams returns the character trigrams of s, padded so short words and
+// word starts weigh in
+func Trigrams(s string) map[string]bool {
+	grams := make(map[string]bool)
+	runes := []rune("  " + s + " ")
+	for i := 0; i+3 <= len(runes); i++ {
+		grams[string(runes[i:i+3])] = true
+	}
+	return grams
+}
+
+// Similarity averages how much of the query the candidate contains with the
+// Dice coefficient, so long titles containing the query are not penalised
+// too much while closer lengths still rank higher
+func Similarity(query, candidate map[string]bool) float64 {
+	if len(query) == 0 || len(candidate) == 0 {
+		return 0
+	}
+	shared := 0
+	for g := range query {
+		if candidate[g] {
+			shared++
+		}
+	}
+	containment := float64(shared) / float64(len(query))
+	dice := 2 * float64(shared) / float64(len(query)+len(candidate))
+	return (containment + dice) / 2
+}
@@ -0,0 +1,69 @@
+package relevance
+
+import "testing"
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"One Piece", "one piece"},
+		{"Re:Zero - Kara", "re zero kara"},
+		{"  Spy×Family!! ", "spy family"},
+		{"Shōnen", "shonen"},
+		{"Shounen", "shonen"},
+		{"Yuusha", "yusha"},
+		{"Pokémon", "pokemon"},
+		{"Kaguya-sama", "kaguya sama"},
+		{"Dr. STONE", "dr stone"},
+		{"86", "86"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Fold(tt.in); got != tt.want {
+			t.Errorf("Fold(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		titles   []string
+		min, max float64
+	}{
+		{"exact", "One Piece", []string{"One Piece"}, 1, 1},
+		{"exact after folding", "shoujo", []string{"Shōjo"}, 1, 1},
+		{"prefix", "one piece", []string{"One Piece Film Red"}, 0.9, 0.9},
+		{"all words", "piece one", []string{"One Piece"}, 0.8, 0.8},
+		{"word prefixes", "kimetsu yai", []string{"Kimetsu no Yaiba"}, 0.8, 0.8},
+		{"alias", "Attack on Titan", []string{"Shingeki no Kyojin"}, 1, 1},
+		{"noise words and suffixes", "nonton one piece sub indo", []string{"One Piece Subtitle Indonesia"}, 1, 1},
+		{"komik prefix", "one piece", []string{"Komik One Piece"}, 1, 1},
+		{"best of several titles", "frieren", []string{"Sousou no Frieren", "Frieren"}, 1, 1},
+		{"similar", "naruto shipuden", []string{"Naruto Shippuden"}, 0.3, 0.7},
+		{"unrelated", "naruto", []string{"Bleach"}, 0, 0.2},
+		{"empty query", "", []string{"One Piece"}, 0, 0},
+		{"no titles", "one piece", nil, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Score(tt.query, tt.titles...)
+			if got < tt.min || got > tt.max {
+				t.Errorf("Score(%q, %q) = %.3f, want in [%.2f, %.2f]", tt.query, tt.titles, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestRankKeepsSiteOrderForTies(t *testing.T) {
+	items := []string{"Bleach", "One Piece Film Red", "One Piece", "One Piece Film Gold"}
+	got := Rank(items, "one piece", func(s string) []string { return []string{s} })
+	want := []string{"One Piece", "One Piece Film Red", "One Piece Film Gold", "Bleach"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Rank = %q, want %q", got, want)
+		}
+	}
+}
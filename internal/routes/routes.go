@@ -1,13 +1,28 @@
 package routes
 
 import (
+	"komiku-scraper/internal/api/middleware"
 	"komiku-scraper/internal/handler"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SetupRoutes(app *fiber.App, komikuHandler *handler.KomikuHandler, winbuHandler *handler.WinbuHandler) {
+func SetupRoutes(app *fiber.App, komikuHandler *handler.KomikuHandler, winbuHandler *handler.WinbuHandler, downloadHandler *handler.DownloadHandler, catalogHandler *handler.CatalogHandler, searchHandler *handler.SearchHandler, relatedHandler *handler.RelatedHandler, userHandler *handler.UserHandler, webhookHandler *handler.WebhookHandler, feedHandler *handler.FeedHandler, jobsHandler *handler.JobsHandler) {
 	api := app.Group("/api/v1")
+	requireAdmin := middleware.RequireAdminKey()
+
+	// RSS 2.0 (.xml), Atom (.atom) and JSON Feed (.json) for feed readers
+	feeds := app.Group("/feeds")
+	feeds.Get("/:provider/latest.:format", feedHandler.Latest) // winbu: ?kind=anime|movie
+	feeds.Get("/:provider/series/:file", feedHandler.Series)   // <slug>.<format>, split at the last dot
+	feeds.Get("/:provider/genre/:file", feedHandler.Genre)     // <genre>.<format>
+
+	// Background cache warming jobs
+	api.Get("/jobs", jobsHandler.List)
+	api.Post("/jobs/:name/run", requireAdmin, jobsHandler.Run) // ADMIN_API_KEY required
+
+	// Unified search across all providers
+	api.Get("/search", searchHandler.Search) // ?q=&providers=komiku,winbu
 
 	// Komiku Routes
 	komiku := api.Group("/komiku")
@@ -16,6 +31,8 @@ func SetupRoutes(app *fiber.App, komikuHandler *handler.KomikuHandler, winbuHand
 	komiku.Get("/manga/:endpoint", komikuHandler.Detail)
 	komiku.Get("/chapter/:endpoint", komikuHandler.Chapter)
 	komiku.Get("/genres", komikuHandler.Genres)
+	komiku.Get("/trending", komikuHandler.Trending) // ?period=daily|weekly|all&type=manga|manhwa|manhua
+	komiku.Get("/browse", komikuHandler.Browse)     // ?type=&genre=&status=&sort=&page=
 
 	// Winbu Routes
 	winbu := api.Group("/winbu")
@@ -25,4 +42,48 @@ func SetupRoutes(app *fiber.App, komikuHandler *handler.KomikuHandler, winbuHand
 	winbu.Get("/episode/:endpoint", winbuHandler.Episode)
 	winbu.Get("/drama", winbuHandler.Drama)
 	winbu.Get("/genres", winbuHandler.Genres)
+	winbu.Get("/subtitle", winbuHandler.Subtitle)                                                   // SRT/ASS -> WebVTT
+	winbu.Get("/schedule", winbuHandler.Schedule)                                                   // ?day=today|monday|senin
+	winbu.Get("/index", winbuHandler.Index)                                                         // ?kind=&letter=|year=|status=&page=
+	winbu.Get("/catalogue", middleware.RequireAdminKeyIf(refreshRequested), winbuHandler.Catalogue) // refresh=true needs ADMIN_API_KEY
+
+	// Local catalogue built from crawls and detail fetches
+	api.Get("/catalog/search", catalogHandler.Search) // ?q=&provider=&genre=&status=&type=&sort=&page=&limit=
+
+	// Manga <-> anime adaptation links
+	related := api.Group("/related")
+	related.Get("/:provider/:slug", relatedHandler.Get)
+	related.Post("/:provider/:slug", requireAdmin, relatedHandler.Confirm)  // {"provider": "winbu", "slug": "..."}
+	related.Delete("/:provider/:slug", requireAdmin, relatedHandler.Reject) // same body
+
+	// Users and reading/watching progress (Authorization: Bearer <token>)
+	api.Post("/users", userHandler.Register)
+	me := api.Group("/me", userHandler.RequireUser)
+	me.Get("/", userHandler.Me)
+	me.Post("/tokens", userHandler.CreateToken)
+	me.Delete("/tokens/current", userHandler.RevokeToken)
+	me.Post("/progress", userHandler.SaveProgress)
+	me.Get("/progress", userHandler.ListProgress) // ?provider=&series=
+	me.Get("/history", userHandler.History)       // ?page=&limit=
+	me.Get("/continue", userHandler.Continue)     // ?provider=komiku|winbu&limit=
+	me.Post("/follows", userHandler.Follow)
+	me.Get("/follows", userHandler.ListFollows)
+	me.Delete("/follows/:provider/:series", userHandler.Unfollow)
+	me.Get("/releases", userHandler.Releases) // ?since=RFC3339&limit=
+	me.Post("/webhooks", webhookHandler.Create)
+	me.Get("/webhooks", webhookHandler.List)
+	me.Get("/webhooks/dead-letters", webhookHandler.DeadLetters) // ?limit=
+	me.Delete("/webhooks/:id", webhookHandler.Delete)
+	me.Post("/webhooks/:id/test", webhookHandler.Test)
+
+	// Download Routes
+	downloads := api.Group("/downloads")
+	downloads.Post("/komiku", requireAdmin, downloadHandler.CreateChapter) // ADMIN_API_KEY required
+	downloads.Get("/:id", downloadHandler.Get)
+	downloads.Get("/:id/events", downloadHandler.Events) // Server-Sent Events
+}
+
+// refreshRequested matches requests asking to rebuild a cached result
+func refreshRequested(c *fiber.Ctx) bool {
+	return c.QueryBool("refresh")
 }
@@ -0,0 +1,217 @@
+// Package scheduler runs named jobs on fixed intervals with jitter and a
+// cap on how many run at once
+package scheduler
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Status is the state of one job as shown by the status endpoint
+type Status struct {
+	Name         string     `json:"name"`
+	Interval     string     `json:"interval"`
+	Running      bool       `json:"running"`
+	Runs         int        `json:"runs"`
+	Failures     int        `json:"failures"`
+	LastStart    *time.Time `json:"last_start"`
+	LastDuration string     `json:"last_duration"`
+	LastError    string     `json:"last_error"`
+	NextRun      *time.Time `json:"next_run"`
+}
+
+type job struct {
+	name     string
+	interval time.Duration
+	run      func() error
+	trigger  chan struct{}
+
+	mu     sync.Mutex
+	status Status
+}
+
+// Scheduler runs jobs registered with Add once Start is called
+type Scheduler struct {
+	Jitter float64 // fraction of the interval each wait is randomly moved by
+
+	jobs    map[string]*job
+	slots   chan struct{}
+	started bool
+	mu      sync.Mutex
+}
+
+// New creates a scheduler. SCHEDULER_CONCURRENCY caps the jobs running at
+// once (default 2) and SCHEDULER_JITTER sets Jitter (default 0.1).
+func New() *Scheduler {
+	concurrency := 2
+	if v, err := strconv.Atoi(os.Getenv("SCHEDULER_CONCURRENCY")); err == nil && v > 0 {
+		concurrency = v
+	}
+	jitter := 0.1
+	if v, err := strconv.ParseFloat(os.Getenv("SCHEDULER_JITTER"), 64); err == nil && v >= 0 && v < 1 {
+		jitter = v
+	}
+	return &Scheduler{
+		Jitter: jitter,
+		jobs:   make(map[string]*job),
+		slots:  make(chan struct{}, concurrency),
+	}
+}
+
+// Add registers a job. A zero interval leaves it out, so callers can pass
+// IntervalFromEnv results straight through.
+func (s *Scheduler) Add(name string, interval time.Duration, run func() error) {
+	if interval <= 0 {
+		log.Printf("[Scheduler] Job %s disabled", name)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[name] = &job{
+		name:     name,
+		interval: interval,
+		run:      run,
+		trigger:  make(chan struct{}, 1),
+		status:   Status{Name: name, Interval: interval.String()},
+	}
+}
+
+// Start launches every job. Each first runs after a random part of its
+// jitter window, so jobs added together do not all fire at boot.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+	for _, j := range s.jobs {
+		go s.loop(j)
+	}
+	log.Printf("[Scheduler] Started %d jobs", len(s.jobs))
+}
+
+// RunNow queues a job to run as soon as a slot is free
+func (s *Scheduler) RunNow(name string) error {
+	s.mu.Lock()
+	j, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("unknown job %q", name)
+	}
+	select {
+	case j.trigger <- struct{}{}:
+	default: // already queued
+	}
+	return nil
+}
+
+// Statuses lists every job, sorted by name
+func (s *Scheduler) Statuses() []Status {
+	s.mu.Lock()
+	jobs := make([]*job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	s.mu.Unlock()
+
+	list := make([]Status, 0, len(jobs))
+	for _, j := range jobs {
+		j.mu.Lock()
+		list = append(list, j.status)
+		j.mu.Unlock()
+	}
+	sort.Slice(list, func(a, b int) bool { return list[a].Name < list[b].Name })
+	return list
+}
+
+func (s *Scheduler) loop(j *job) {
+	wait := time.Duration(rand.Float64() * s.Jitter * float64(j.interval))
+	for {
+		next := time.Now().Add(wait)
+		j.mu.Lock()
+		j.status.NextRun = &next
+		j.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-j.trigger:
+			timer.Stop()
+		}
+		s.execute(j)
+		wait = s.jittered(j.interval)
+	}
+}
+
+func (s *Scheduler) execute(j *job) {
+	s.slots <- struct{}{}
+	defer func() { <-s.slots }()
+
+	start := time.Now()
+	j.mu.Lock()
+	j.status.Running = true
+	j.status.LastStart = &start
+	j.status.NextRun = nil
+	j.mu.Unlock()
+
+	err := safeRun(j.run)
+
+	elapsed := time.Since(start)
+	j.mu.Lock()
+	j.status.Running = false
+	j.status.Runs++
+	j.status.LastDuration = elapsed.Round(time.Millisecond).String()
+	j.status.LastError = ""
+	if err != nil {
+		j.status.Failures++
+		j.status.LastError = err.Error()
+	}
+	j.mu.Unlock()
+
+	if err != nil {
+		log.Printf("[Scheduler] Job %s failed after %s: %v", j.name, elapsed.Round(time.Millisecond), err)
+	} else {
+		log.Printf("[Scheduler] Job %s done in %s", j.name, elapsed.Round(time.Millisecond))
+	}
+}
+
+// jittered moves interval randomly by up to Jitter of it either way
+func (s *Scheduler) jittered(interval time.Duration) time.Duration {
+	spread := s.Jitter * float64(interval)
+	return interval + time.Duration((rand.Float64()*2-1)*spread)
+}
+
+// safeRun keeps a panicking job from taking the process down
+func safeRun(run func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return run()
+}
+
+// IntervalFromEnv reads a job interval such as "10m" from key. "off" or
+// "0" disable the job; unset or invalid values give def.
+func IntervalFromEnv(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	if v == "off" || v == "0" {
+		return 0
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		log.Printf("[Scheduler] Invalid %s %q, using %s", key, v, def)
+		return def
+	}
+	return d
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"fmt"
+	"komiku-scraper/internal/catalog"
+	"komiku-scraper/internal/feed"
+	"komiku-scraper/scraper/common"
+	"komiku-scraper/scraper/komiku"
+	"komiku-scraper/scraper/winbu"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// feedLimit bounds the entries of genre feeds
+const feedLimit = 50
+
+// FeedService builds feeds from the home, detail and browse parsers. Every
+// source it reads is cached, so feeds are at most as fresh as the cache.
+type FeedService struct {
+	Komiku  *KomikuService
+	Winbu   *WinbuService
+	Catalog *catalog.Store
+}
+
+func NewFeedService(komikuSvc *KomikuService, winbuSvc *WinbuService, store *catalog.Store) *FeedService {
+	return &FeedService{Komiku: komikuSvc, Winbu: winbuSvc, Catalog: store}
+}
+
+// Latest returns a provider's latest updates. For winbu, kind narrows the
+// feed to KindAnime or KindMovie; empty includes both.
+func (s *FeedService) Latest(provider, kind string) (*feed.Feed, error) {
+	now := time.Now()
+	switch provider {
+	case catalog.ProviderKomiku:
+		home, err := s.Komiku.FetchHomeData()
+		if err != nil {
+			return nil, err
+		}
+		f := &feed.Feed{
+			Title:       "Komiku - Latest Updates",
+			Link:        common.KomikuBaseURL + "/",
+			Description: "Latest manga, manhwa and manhua chapters on komiku",
+			Updated:     now,
+		}
+		for _, m := range home.Latest {
+			f.Items = append(f.Items, mangaItem(m, now))
+		}
+		return f, nil
+
+	case catalog.ProviderWinbu:
+		if kind != "" && kind != KindAnime && kind != KindMovie {
+			return nil, fmt.Errorf("kind must be anime or movie")
+		}
+		home, err := s.Winbu.FetchHomeData()
+		if err != nil {
+			return nil, err
+		}
+		f := &feed.Feed{
+			Title:       "Winbu - Latest Updates",
+			Link:        common.WinbuBaseURL + "/",
+			Description: "Latest anime episodes and movies on winbu",
+			Updated:     now,
+		}
+		if kind != KindMovie {
+			for _, a := range home.LatestAnime {
+				f.Items = append(f.Items, animeItem(a))
+			}
+		}
+		if kind != KindAnime {
+			for _, a := range home.LatestMovies {
+				f.Items = append(f.Items, animeItem(a))
+			}
+		}
+		if kind != "" {
+			f.Title = "Winbu - Latest " + strings.ToUpper(kind[:1]) + kind[1:]
+		}
+		return f, nil
+	}
+	return nil, ErrUnknownProvider
+}
+
+// Series returns the chapters or episodes of one title, newest first
+func (s *FeedService) Series(provider, slug string) (*feed.Feed, error) {
+	now := time.Now()
+	switch provider {
+	case catalog.ProviderKomiku:
+		url := "https://komiku.id/manga/" + slug + "/"
+		detail, err := s.Komiku.FetchAndParseDetail(url)
+		if err != nil {
+			return nil, err
+		}
+		f := &feed.Feed{
+			Title:       detail.Title + " - Komiku",
+			Link:        catalog.AbsoluteURL(provider, "/manga/"+slug+"/"),
+			Description: detail.Synopsis,
+			Updated:     now,
+		}
+		for _, ch := range detail.Chapters {
+			link := catalog.AbsoluteURL(provider, ch.Endpoint)
+			f.Items = append(f.Items, feed.Item{
+				ID:         link,
+				Title:      detail.Title + " - " + ch.Title,
+				Link:       link,
+				Image:      detail.Thumb,
+				Published:  feed.ParseDate(ch.DateUploaded, now),
+				Categories: detail.Genres,
+			})
+		}
+		return f, nil
+
+	case catalog.ProviderWinbu:
+		url, detail, err := s.Winbu.FetchDetailBySlug(slug)
+		if err != nil {
+			return nil, err
+		}
+		f := &feed.Feed{
+			Title:       detail.Title + " - Winbu",
+			Link:        catalog.AbsoluteURL(provider, url),
+			Description: detail.Synopsis,
+			Updated:     now,
+		}
+		var numbers []float64
+		for _, ep := range detail.Episodes {
+			n, _ := strconv.ParseFloat(ep.Number, 64)
+			numbers = append(numbers, n)
+		}
+		episodes := detail.Episodes
+		if isAscending(numbers, true) {
+			episodes = make([]winbu.Episode, len(detail.Episodes))
+			for i, ep := range detail.Episodes {
+				episodes[len(episodes)-1-i] = ep
+			}
+		}
+		for _, ep := range episodes {
+			link := catalog.AbsoluteURL(provider, ep.Endpoint)
+			f.Items = append(f.Items, feed.Item{
+				ID:         link,
+				Title:      detail.Title + " - " + ep.Title,
+				Link:       link,
+				Image:      detail.Thumb,
+				Categories: detail.Genres,
+			})
+		}
+		return f, nil
+	}
+	return nil, ErrUnknownProvider
+}
+
+// Genre returns recently updated titles of a genre. komiku's browse listing
+// is filtered live; winbu has no such listing, so its genre feeds come from
+// the local catalogue.
+func (s *FeedService) Genre(provider, genre string) (*feed.Feed, error) {
+	now := time.Now()
+	switch provider {
+	case catalog.ProviderKomiku:
+		filter := komiku.BrowseFilter{Genres: []string{genre}, Sort: komiku.SortUpdated, Page: 1}
+		result, err := s.Komiku.FetchBrowse(filter)
+		if err != nil {
+			return nil, err
+		}
+		f := &feed.Feed{
+			Title:       "Komiku - " + genreName(genre),
+			Link:        komiku.BrowseURL(filter),
+			Description: "Recently updated " + genreName(genre) + " titles on komiku",
+			Updated:     now,
+		}
+		for _, m := range result.Items {
+			f.Items = append(f.Items, mangaItem(m, now))
+		}
+		return f, nil
+
+	case catalog.ProviderWinbu:
+		if s.Catalog == nil {
+			return nil, fmt.Errorf("catalogue is not available")
+		}
+		result, err := s.Catalog.Search(catalog.Query{Provider: provider, Genres: []string{genre}, Sort: catalog.SortUpdated, Limit: feedLimit})
+		if err != nil {
+			return nil, err
+		}
+		f := &feed.Feed{
+			Title:       "Winbu - " + genreName(genre),
+			Link:        common.WinbuBaseURL + "/genre/" + genre + "/",
+			Description: "Recently updated " + genreName(genre) + " titles on winbu",
+			Updated:     now,
+		}
+		for _, e := range result.Items {
+			link := catalog.AbsoluteURL(provider, e.Endpoint)
+			f.Items = append(f.Items, feed.Item{
+				ID:         link,
+				Title:      e.Title,
+				Link:       link,
+				Image:      e.Thumb,
+				Categories: e.Genres,
+			})
+		}
+		return f, nil
+	}
+	return nil, ErrUnknownProvider
+}
+
+// mangaItem turns a komiku card into an entry for its latest chapter. The
+// chapter is part of the ID, so every new chapter is a new entry.
+func mangaItem(m komiku.Manga, now time.Time) feed.Item {
+	link := catalog.AbsoluteURL(catalog.ProviderKomiku, m.Endpoint)
+	title := m.Title
+	id := link
+	if m.LatestChapter != "" {
+		title += " - " + m.LatestChapter
+		id += "#" + strings.ToLower(strings.Join(strings.Fields(m.LatestChapter), "-"))
+	}
+	var categories []string
+	for _, c := range []string{m.Type, m.Genre} {
+		if c != "" {
+			categories = append(categories, c)
+		}
+	}
+	return feed.Item{
+		ID:         id,
+		Title:      title,
+		Link:       link,
+		Summary:    m.Description,
+		Image:      m.Thumb,
+		Published:  feed.ParseDate(m.UpdatedAt, now),
+		Categories: categories,
+	}
+}
+
+// animeItem turns a winbu card into an entry for its latest episode. As with
+// mangaItem the episode is part of the ID, so a new episode of a series
+// already in the feed is a new entry.
+func animeItem(a winbu.Anime) feed.Item {
+	link := catalog.AbsoluteURL(catalog.ProviderWinbu, a.Endpoint)
+	title := a.Title
+	id := link
+	// Cards show the latest episode as "Ep 12"; episode links carry it too
+	episode := strings.TrimPrefix(a.Status, "Ep ")
+	if episode == a.Status {
+		episode = ""
+		if kind, n := winbu.ParseEpisodeNumber(winbu.SlugFromURL(a.Endpoint)); kind == winbu.KindEpisode {
+			episode = n
+		}
+	}
+	if episode != "" {
+		title += " - Episode " + episode
+		id += "#episode-" + episode
+	}
+	var categories []string
+	if a.Type != "" {
+		categories = append(categories, a.Type)
+	}
+	return feed.Item{ID: id, Title: title, Link: link, Image: a.Thumb, Categories: categories}
+}
+
+// genreName turns a genre slug into a heading: "slice-of-life" gives
+// "Slice Of Life"
+func genreName(slug string) string {
+	words := strings.Fields(strings.ReplaceAll(slug, "-", " "))
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"fmt"
+	"komiku-scraper/internal/catalog"
+	"komiku-scraper/internal/users"
+	"log"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// detailFetchers bounds concurrent detail fetches when building a continue list
+const detailFetchers = 4
+
+// ContinueItem is one series in a "continue reading/watching" list: where
+// the user stopped and what comes next
+type ContinueItem struct {
+	Provider  string          `json:"provider"`
+	Kind      string          `json:"kind"` // KindManga or KindAnime
+	Series    string          `json:"series"`
+	Title     string          `json:"title"`
+	Thumb     string          `json:"thumb"`
+	Last      users.Progress  `json:"last"`
+	Next      *ContinueTarget `json:"next"`      // chapter/episode after Last once it is completed
+	Remaining int             `json:"remaining"` // chapters/episodes after Last, -1 when unknown
+}
+
+// ContinueTarget is a chapter or episode to open
+type ContinueTarget struct {
+	Slug     string `json:"slug"`
+	Title    string `json:"title"`
+	Endpoint string `json:"endpoint"`
+}
+
+// ProgressInput is a progress report from a reader or player
+type ProgressInput struct {
+	Provider  string  `json:"provider"`
+	Series    string  `json:"series"`
+	Item      string  `json:"item"`
+	ItemTitle string  `json:"item_title"`
+	Page      int     `json:"page"`
+	Position  float64 `json:"position"`
+	Duration  float64 `json:"duration"`
+	Completed bool    `json:"completed"`
+}
+
+// ProgressService records reading/watching progress and builds continue lists
+type ProgressService struct {
+	Users   *users.Store
+	Catalog *catalog.Store
+	Komiku  *KomikuService
+	Winbu   *WinbuService
+}
+
+func NewProgressService(store *users.Store, catalogStore *catalog.Store, komikuSvc *KomikuService, winbuSvc *WinbuService) *ProgressService {
+	return &ProgressService{Users: store, Catalog: catalogStore, Komiku: komikuSvc, Winbu: winbuSvc}
+}
+
+// Record validates and stores a progress report. Episodes watched past 90%
+// count as completed.
+func (s *ProgressService) Record(userID int64, in ProgressInput) error {
+	if _, err := OtherProvider(in.Provider); err != nil {
+		return err
+	}
+	if in.Series == "" || in.Item == "" {
+		return fmt.Errorf("series and item are required")
+	}
+	if in.Page < 0 || in.Position < 0 || in.Duration < 0 {
+		return fmt.Errorf("page, position and duration cannot be negative")
+	}
+
+	event := users.EventRead
+	if in.Provider == catalog.ProviderWinbu {
+		event = users.EventWatched
+		if in.Duration > 0 && in.Position >= in.Duration*0.9 {
+			in.Completed = true
+		}
+	}
+	return s.Users.SaveProgress(userID, users.Progress{
+		Provider:  in.Provider,
+		Series:    in.Series,
+		Item:      in.Item,
+		ItemTitle: in.ItemTitle,
+		Page:      in.Page,
+		Position:  in.Position,
+		Duration:  in.Duration,
+		Completed: in.Completed,
+	}, event)
+}
+
+// Continue lists the series the user was last in, newest first, with the
+// series details and the next chapter or episode
+func (s *ProgressService) Continue(userID int64, provider string, limit int) ([]ContinueItem, error) {
+	if provider != "" {
+		if _, err := OtherProvider(provider); err != nil {
+			return nil, err
+		}
+	}
+	latest, err := s.Users.LatestPerSeries(userID, provider, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]ContinueItem, len(latest))
+	sem := make(chan struct{}, detailFetchers)
+	var wg sync.WaitGroup
+	for i, p := range latest {
+		wg.Add(1)
+		go func(i int, p users.Progress) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			items[i] = s.continueItem(p)
+		}(i, p)
+	}
+	wg.Wait()
+	return items, nil
+}
+
+func (s *ProgressService) continueItem(p users.Progress) ContinueItem {
+	item := ContinueItem{Provider: p.Provider, Series: p.Series, Last: p, Remaining: -1}
+	if e, _ := s.Catalog.Get(p.Provider, p.Series); e != nil {
+		item.Title, item.Thumb = e.Title, e.Thumb
+	}
+
+	// Details are cached for an hour, so a busy list costs few fetches
+	var targets []ContinueTarget
+	ascending := false
+	switch p.Provider {
+	case catalog.ProviderKomiku:
+		item.Kind = KindManga
+		detail, err := s.Komiku.FetchAndParseDetail("https://komiku.id/manga/" + p.Series + "/")
+		if err != nil {
+			log.Printf("[Progress] No detail for komiku/%s: %v", p.Series, err)
+			return item
+		}
+		item.Title, item.Thumb = detail.Title, detail.Thumb
+		var numbers []float64
+		for _, ch := range detail.Chapters {
+			targets = append(targets, ContinueTarget{Slug: catalog.SlugFromEndpoint(ch.Endpoint), Title: ch.Title, Endpoint: ch.Endpoint})
+			numbers = append(numbers, firstNumber(ch.Title))
+		}
+		// komiku lists the newest chapter first
+		ascending = isAscending(numbers, false)
+	case catalog.ProviderWinbu:
+		item.Kind = KindAnime
+		_, detail, err := s.Winbu.FetchDetailBySlug(p.Series)
+		if err != nil {
+			log.Printf("[Progress] No detail for winbu/%s: %v", p.Series, err)
+			return item
+		}
+		item.Title, item.Thumb = detail.Title, detail.Thumb
+		var numbers []float64
+		for _, ep := range detail.Episodes {
+			targets = append(targets, ContinueTarget{Slug: catalog.SlugFromEndpoint(ep.Endpoint), Title: ep.Title, Endpoint: ep.Endpoint})
+			n, _ := strconv.ParseFloat(ep.Number, 64)
+			numbers = append(numbers, n)
+		}
+		ascending = isAscending(numbers, true)
+	}
+
+	current := -1
+	for i, t := range targets {
+		if t.Slug == p.Item {
+			current = i
+			break
+		}
+	}
+	if current < 0 {
+		return item
+	}
+	next := current - 1
+	item.Remaining = current
+	if ascending {
+		next = current + 1
+		item.Remaining = len(targets) - 1 - current
+	}
+	if p.Completed && next >= 0 && next < len(targets) {
+		item.Next = &targets[next]
+	}
+	return item
+}
+
+var numberInTitle = regexp.MustCompile(`\d+(?:\.\d+)?`)
+
+// firstNumber returns the first number in a chapter title, 0 when none
+func firstNumber(title string) float64 {
+	n, _ := strconv.ParseFloat(numberInTitle.FindString(title), 64)
+	return n
+}
+
+// isAscending tells whether a chapter/episode list runs oldest first from
+// the numbers at its ends, falling back to the provider's usual order
+func isAscending(numbers []float64, fallback bool) bool {
+	if len(numbers) < 2 {
+		return fallback
+	}
+	first, last := numbers[0], numbers[len(numbers)-1]
+	if first == 0 || last == 0 || first == last {
+		return fallback
+	}
+	return first < last
+}
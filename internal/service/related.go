@@ -0,0 +1,289 @@
+package service
+
+import (
+	"fmt"
+	"komiku-scraper/internal/catalog"
+	"komiku-scraper/internal/relevance"
+	"log"
+	"sort"
+)
+
+const (
+	// linkThreshold is the match score stored as a link without confirmation
+	linkThreshold = 0.9
+	// candidateThreshold is the lowest score offered for manual confirmation
+	candidateThreshold = 0.6
+	// maxMatchTitles bounds how many of a title's names are searched for
+	maxMatchTitles = 3
+)
+
+// ErrUnknownProvider is returned for provider names other than komiku and winbu
+var ErrUnknownProvider = fmt.Errorf("provider must be komiku or winbu")
+
+// RelatedItem is a work on the other provider
+type RelatedItem struct {
+	Provider string  `json:"provider"`
+	Slug     string  `json:"slug"`
+	Title    string  `json:"title"`
+	Endpoint string  `json:"endpoint"`
+	Thumb    string  `json:"thumb"`
+	Type     string  `json:"type"`
+	Score    float64 `json:"score"`
+	Source   string  `json:"source,omitempty"` // catalog.LinkAuto or catalog.LinkManual for links
+}
+
+// Related lists the confirmed links of a work and likely matches awaiting
+// confirmation
+type Related struct {
+	Provider   string        `json:"provider"`
+	Slug       string        `json:"slug"`
+	Title      string        `json:"title"`
+	Links      []RelatedItem `json:"links"`
+	Candidates []RelatedItem `json:"candidates"`
+}
+
+// RelatedService links komiku manga to their winbu adaptations and back
+type RelatedService struct {
+	Catalog *catalog.Store
+	Komiku  *KomikuService
+	Winbu   *WinbuService
+}
+
+func NewRelatedService(store *catalog.Store, komikuSvc *KomikuService, winbuSvc *WinbuService) *RelatedService {
+	return &RelatedService{Catalog: store, Komiku: komikuSvc, Winbu: winbuSvc}
+}
+
+// OtherProvider returns the provider adaptations are looked up on
+func OtherProvider(provider string) (string, error) {
+	switch provider {
+	case catalog.ProviderKomiku:
+		return catalog.ProviderWinbu, nil
+	case catalog.ProviderWinbu:
+		return catalog.ProviderKomiku, nil
+	}
+	return "", ErrUnknownProvider
+}
+
+// Related returns the works on the other provider telling the same story.
+// Stored links are answered from the catalogue; otherwise, or with refresh,
+// the other provider is searched for every name of the work, matches scoring
+// at least linkThreshold are stored and weaker ones listed as candidates.
+func (s *RelatedService) Related(provider, slug string, refresh bool) (*Related, error) {
+	other, err := OtherProvider(provider)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.Catalog.Links(provider, slug)
+	if err != nil {
+		return nil, err
+	}
+	result := &Related{Provider: provider, Slug: slug, Links: []RelatedItem{}, Candidates: []RelatedItem{}}
+	if hasConfirmed(stored) && !refresh {
+		if e, _ := s.Catalog.Get(provider, slug); e != nil {
+			result.Title = e.Title
+		}
+		result.Links = s.linkItems(stored, nil)
+		return result, nil
+	}
+
+	source, err := s.fetchEntry(provider, slug)
+	if err != nil {
+		return nil, err
+	}
+	result.Title = source.Title
+
+	decided := make(map[string]bool) // slugs confirmed or rejected through the API
+	for _, l := range stored {
+		if l.Source != catalog.LinkAuto {
+			decided[l.OtherSlug] = true
+		}
+	}
+
+	scored := make(map[string]RelatedItem)
+	for _, c := range s.candidates(source, other) {
+		score := relevance.Match(titlesOf(source), titlesOf(c))
+		if score < candidateThreshold || decided[c.Slug] {
+			continue
+		}
+		item := RelatedItem{Provider: other, Slug: c.Slug, Title: c.Title, Endpoint: c.Endpoint, Thumb: c.Thumb, Type: c.Type, Score: score}
+		if score >= linkThreshold {
+			err := s.Catalog.SaveLink(catalog.Link{Provider: provider, Slug: slug, OtherProvider: other, OtherSlug: c.Slug, Score: score, Source: catalog.LinkAuto})
+			if err != nil {
+				log.Printf("[Related] Failed to store link %s/%s -> %s/%s: %v", provider, slug, other, c.Slug, err)
+			}
+		}
+		scored[c.Slug] = item
+	}
+
+	// Re-read so links stored earlier or just now come back in one list
+	stored, err = s.Catalog.Links(provider, slug)
+	if err != nil {
+		return nil, err
+	}
+	result.Links = s.linkItems(stored, scored)
+	linked := make(map[string]bool)
+	for _, l := range result.Links {
+		linked[l.Slug] = true
+	}
+	for _, item := range scored {
+		switch {
+		case linked[item.Slug]:
+		case item.Score >= linkThreshold:
+			// Without a catalogue nothing is stored; still report the match
+			item.Source = catalog.LinkAuto
+			result.Links = append(result.Links, item)
+		default:
+			result.Candidates = append(result.Candidates, item)
+		}
+	}
+	sortRelated(result.Links)
+	sortRelated(result.Candidates)
+	return result, nil
+}
+
+// Confirm stores a link chosen by a user, replacing any automatic decision
+func (s *RelatedService) Confirm(provider, slug, otherProvider, otherSlug string) error {
+	return s.decide(provider, slug, otherProvider, otherSlug, catalog.LinkManual)
+}
+
+// Reject records that two works are not the same story, so matching stops
+// offering the pair
+func (s *RelatedService) Reject(provider, slug, otherProvider, otherSlug string) error {
+	return s.decide(provider, slug, otherProvider, otherSlug, catalog.LinkRejected)
+}
+
+func (s *RelatedService) decide(provider, slug, otherProvider, otherSlug, source string) error {
+	other, err := OtherProvider(provider)
+	if err != nil {
+		return err
+	}
+	if otherProvider != other {
+		return fmt.Errorf("links join komiku and winbu, %s/%s can only link to %s", provider, slug, other)
+	}
+	if slug == "" || otherSlug == "" {
+		return fmt.Errorf("both slugs are required")
+	}
+	if s.Catalog == nil {
+		return fmt.Errorf("catalogue is not available")
+	}
+
+	score := 1.0
+	if source == catalog.LinkRejected {
+		score = 0
+	}
+	return s.Catalog.SaveLink(catalog.Link{Provider: provider, Slug: slug, OtherProvider: otherProvider, OtherSlug: otherSlug, Score: score, Source: source})
+}
+
+// fetchEntry loads a work's detail page, which also refreshes its catalogue
+// entry with alternative titles
+func (s *RelatedService) fetchEntry(provider, slug string) (catalog.Entry, error) {
+	if provider == catalog.ProviderKomiku {
+		url := "https://komiku.id/manga/" + slug + "/"
+		detail, err := s.Komiku.FetchAndParseDetail(url)
+		if err != nil {
+			return catalog.Entry{}, err
+		}
+		return catalog.FromMangaDetail(url, detail), nil
+	}
+
+	url, detail, err := s.Winbu.FetchDetailBySlug(slug)
+	if err != nil {
+		return catalog.Entry{}, err
+	}
+	return catalog.FromAnimeDetail(url, detail), nil
+}
+
+// candidates gathers works on the other provider sharing a name with source,
+// from the catalogue and from a live search of the other site
+func (s *RelatedService) candidates(source catalog.Entry, other string) []catalog.Entry {
+	found := make(map[string]catalog.Entry)
+	var order []string
+	add := func(e catalog.Entry) {
+		if e.Slug == "" {
+			return
+		}
+		if _, ok := found[e.Slug]; !ok {
+			order = append(order, e.Slug)
+		}
+		// Prefer the catalogue's copy, it carries alternative titles
+		if known, ok := found[e.Slug]; !ok || len(e.AltTitles) > len(known.AltTitles) {
+			found[e.Slug] = e
+		}
+	}
+
+	var provider SearchProvider = s.Winbu
+	if other == catalog.ProviderKomiku {
+		provider = s.Komiku
+	}
+
+	titles := titlesOf(source)
+	for i, title := range titles {
+		if i == maxMatchTitles {
+			break
+		}
+		if matches, err := s.Catalog.Search(catalog.Query{Text: title, Provider: other}); err == nil {
+			for _, e := range matches.Items {
+				add(e)
+			}
+		}
+
+		items, err := provider.SearchItems(title)
+		if err != nil {
+			log.Printf("[Related] %s search for %q failed: %v", other, title, err)
+			continue
+		}
+		for _, item := range items {
+			e := catalog.Entry{Provider: other, Slug: item.Slug, Title: item.Title, Endpoint: item.Endpoint, Thumb: item.Thumb, Type: item.Type}
+			if known, _ := s.Catalog.Get(other, item.Slug); known != nil {
+				e = *known
+			}
+			add(e)
+		}
+	}
+
+	result := make([]catalog.Entry, 0, len(order))
+	for _, slug := range order {
+		result = append(result, found[slug])
+	}
+	return result
+}
+
+// linkItems turns stored links into items, skipping rejected ones. Details
+// come from scored matches when available, else from the catalogue.
+func (s *RelatedService) linkItems(links []catalog.Link, scored map[string]RelatedItem) []RelatedItem {
+	items := []RelatedItem{}
+	for _, l := range links {
+		if l.Source == catalog.LinkRejected {
+			continue
+		}
+		item, ok := scored[l.OtherSlug]
+		if !ok {
+			item = RelatedItem{Provider: l.OtherProvider, Slug: l.OtherSlug}
+			if e, _ := s.Catalog.Get(l.OtherProvider, l.OtherSlug); e != nil {
+				item.Title, item.Endpoint, item.Thumb, item.Type = e.Title, e.Endpoint, e.Thumb, e.Type
+			}
+		}
+		item.Score = l.Score
+		item.Source = l.Source
+		items = append(items, item)
+	}
+	return items
+}
+
+func hasConfirmed(links []catalog.Link) bool {
+	for _, l := range links {
+		if l.Source != catalog.LinkRejected {
+			return true
+		}
+	}
+	return false
+}
+
+func titlesOf(e catalog.Entry) []string {
+	return append([]string{e.Title}, e.AltTitles...)
+}
+
+func sortRelated(items []RelatedItem) {
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Score > items[j].Score })
+}
@@ -0,0 +1,221 @@
+package service
+
+import (
+	"fmt"
+	"komiku-scraper/internal/catalog"
+	"komiku-scraper/internal/users"
+	"log"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultPollInterval is used when RELEASE_POLL_INTERVAL is unset
+	defaultPollInterval = 30 * time.Minute
+	// pollDelay spaces out detail fetches within one poll
+	pollDelay = 2 * time.Second
+	// minSnapshotRatio is the smallest fraction of the previous item count a
+	// new listing may have; shorter ones are taken for a broken page
+	minSnapshotRatio = 0.5
+)
+
+// ErrSeriesNotFound is returned by detail fetches and Check when the source
+// has no such series
+var ErrSeriesNotFound = fmt.Errorf("series not found")
+
+// ReleasePoller refetches followed series and records chapters and episodes
+// that were not there at the previous poll
+type ReleasePoller struct {
+	Users    *users.Store
+	Komiku   *KomikuService // background priority, used by polls
+	Winbu    *WinbuService
+	Interval time.Duration
+
+	// LiveKomiku and LiveWinbu serve checks a user is waiting on, see CheckNow
+	LiveKomiku *KomikuService
+	LiveWinbu  *WinbuService
+
+	// OnRelease, when set, receives the releases each check detects
+	OnRelease func([]users.Release)
+
+	polling atomic.Bool
+	checks  sync.Map // "provider/series" -> *sync.Mutex, one check at a time
+}
+
+// NewReleasePoller polls through background copies of the given services
+// and keeps the services themselves for CheckNow
+func NewReleasePoller(store *users.Store, komikuSvc *KomikuService, winbuSvc *WinbuService) *ReleasePoller {
+	interval := defaultPollInterval
+	if v := os.Getenv("RELEASE_POLL_INTERVAL"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			interval = d
+		} else {
+			log.Printf("[Releases] Invalid RELEASE_POLL_INTERVAL %q, using %s", v, interval)
+		}
+	}
+	return &ReleasePoller{
+		Users:      store,
+		Komiku:     komikuSvc.Background(),
+		Winbu:      winbuSvc.Background(),
+		LiveKomiku: komikuSvc,
+		LiveWinbu:  winbuSvc,
+		Interval:   interval,
+	}
+}
+
+// Run polls every Interval until the process exits
+func (p *ReleasePoller) Run() {
+	if p.Users == nil {
+		return
+	}
+	log.Printf("[Releases] Polling followed series every %s", p.Interval)
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		p.PollOnce()
+	}
+}
+
+// PollOnce checks every followed series once, least recently checked first.
+// It returns immediately if a poll is already running.
+func (p *ReleasePoller) PollOnce() {
+	if !p.polling.CompareAndSwap(false, true) {
+		return
+	}
+	defer p.polling.Store(false)
+
+	series, err := p.Users.FollowedSeries()
+	if err != nil {
+		log.Printf("[Releases] Failed to list followed series: %v", err)
+		return
+	}
+
+	start := time.Now()
+	found := 0
+	for i, ref := range series {
+		if i > 0 {
+			time.Sleep(pollDelay)
+		}
+		releases, err := p.Check(ref.Provider, ref.Series)
+		if err != nil {
+			log.Printf("[Releases] Check of %s/%s failed: %v", ref.Provider, ref.Series, err)
+			continue
+		}
+		found += len(releases)
+	}
+	log.Printf("[Releases] Checked %d series in %s, %d new releases", len(series), time.Since(start).Round(time.Second), found)
+}
+
+// Check fetches a series' current chapter/episode list, bypassing the detail
+// cache, and stores it as the new snapshot. Items missing from the previous
+// snapshot are recorded and returned as releases; the first check of a
+// series only takes the snapshot, which may be empty for a series without
+// chapters yet. A listing that lost all or most of its items is rejected and
+// the previous snapshot kept: it is far more likely a block or error page
+// than a series losing its chapters, and saving it would report every item
+// as new on the next good poll. Checks of one series never overlap, so a
+// release is recorded and dispatched once.
+func (p *ReleasePoller) Check(provider, series string) ([]users.Release, error) {
+	return p.check(provider, series, p.Komiku, p.Winbu)
+}
+
+// CheckNow is Check for a request a user is waiting on: it fetches at
+// interactive priority instead of queueing behind crawls and cache warming
+func (p *ReleasePoller) CheckNow(provider, series string) ([]users.Release, error) {
+	return p.check(provider, series, p.LiveKomiku, p.LiveWinbu)
+}
+
+func (p *ReleasePoller) check(provider, series string, komikuSvc *KomikuService, winbuSvc *WinbuService) ([]users.Release, error) {
+	lock, _ := p.checks.LoadOrStore(provider+"/"+series, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	snap, current, err := p.fetchItems(provider, series, komikuSvc, winbuSvc)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := p.Users.Snapshot(provider, series)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(current) == 0 && previous != nil && len(previous.Items) > 0 {
+		return nil, fmt.Errorf("listing has no items, keeping previous snapshot")
+	}
+	if previous != nil && float64(len(current)) < float64(len(previous.Items))*minSnapshotRatio {
+		return nil, fmt.Errorf("listing shrank from %d to %d items, keeping previous snapshot", len(previous.Items), len(current))
+	}
+
+	var releases []users.Release
+	if previous != nil {
+		known := make(map[string]bool, len(previous.Items))
+		for _, item := range previous.Items {
+			known[item] = true
+		}
+		for _, r := range current {
+			if !known[r.Item] {
+				r.Provider, r.Series, r.SeriesTitle, r.DetectedAt = provider, series, snap.Title, snap.CheckedAt
+				releases = append(releases, r)
+			}
+		}
+	}
+
+	if err := p.Users.SaveSnapshot(snap, releases); err != nil {
+		return nil, err
+	}
+	if len(releases) > 0 {
+		log.Printf("[Releases] %s/%s: %d new", provider, series, len(releases))
+		if p.OnRelease != nil {
+			p.OnRelease(releases)
+		}
+	}
+	return releases, nil
+}
+
+// fetchItems returns the snapshot of a series and its items as releases
+// (without detection fields), in the provider's listing order
+func (p *ReleasePoller) fetchItems(provider, series string, komikuSvc *KomikuService, winbuSvc *WinbuService) (users.Snapshot, []users.Release, error) {
+	snap := users.Snapshot{Provider: provider, Series: series, CheckedAt: time.Now().UTC()}
+	var items []users.Release
+
+	switch provider {
+	case catalog.ProviderKomiku:
+		detail, err := komikuSvc.RefreshDetail("https://komiku.id/manga/" + series + "/")
+		if err != nil {
+			return snap, nil, err
+		}
+		if detail.Title == "" {
+			return snap, nil, ErrSeriesNotFound
+		}
+		snap.Title = detail.Title
+		for _, ch := range detail.Chapters {
+			items = append(items, users.Release{Item: catalog.SlugFromEndpoint(ch.Endpoint), ItemTitle: ch.Title, Endpoint: ch.Endpoint})
+		}
+	case catalog.ProviderWinbu:
+		url, _, err := winbuSvc.FetchDetailBySlug(series)
+		if err != nil {
+			return snap, nil, err
+		}
+		detail, err := winbuSvc.RefreshDetail(url)
+		if err != nil {
+			return snap, nil, err
+		}
+		if detail.Title == "" {
+			return snap, nil, ErrSeriesNotFound
+		}
+		snap.Title = detail.Title
+		for _, ep := range detail.Episodes {
+			items = append(items, users.Release{Item: catalog.SlugFromEndpoint(ep.Endpoint), ItemTitle: ep.Title, Endpoint: ep.Endpoint})
+		}
+	default:
+		return snap, nil, ErrUnknownProvider
+	}
+
+	for _, r := range items {
+		snap.Items = append(snap.Items, r.Item)
+	}
+	return snap, items, nil
+}
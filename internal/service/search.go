@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"komiku-scraper/internal/catalog"
+	"komiku-scraper/internal/relevance"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of search items
+const (
+	KindManga = "manga" // komiku manga, manhwa and manhua
+	KindAnime = "anime"
+	KindMovie = "movie"
+)
+
+// SearchItem is a search hit in the shape shared by every provider
+type SearchItem struct {
+	Provider string `json:"provider"`
+	Kind     string `json:"kind"`
+	Title    string `json:"title"`
+	Slug     string `json:"slug"`
+	Endpoint string `json:"endpoint"`
+	Thumb    string `json:"thumb"`
+	Type     string `json:"type"` // provider label: "Manhwa", "Series", "Movie", ...
+	Status   string `json:"status,omitempty"`
+	Score    string `json:"score,omitempty"`
+}
+
+// SearchProvider is a site the unified search fans out to
+type SearchProvider interface {
+	ProviderName() string
+	SearchItems(query string) ([]SearchItem, error)
+}
+
+// ProviderStatus reports how one provider answered a unified search
+type ProviderStatus struct {
+	Provider string `json:"provider"`
+	OK       bool   `json:"ok"`
+	Count    int    `json:"count"`
+	Error    string `json:"error,omitempty"`
+	TookMS   int64  `json:"took_ms"`
+}
+
+// SearchResponse holds the merged items and the per-provider outcome
+type SearchResponse struct {
+	Query     string           `json:"query"`
+	Items     []SearchItem     `json:"items"`
+	Providers []ProviderStatus `json:"providers"`
+}
+
+// defaultSearchTimeout bounds each provider when SEARCH_PROVIDER_TIMEOUT is unset
+const defaultSearchTimeout = 10 * time.Second
+
+// SearchService runs one query against every registered provider
+type SearchService struct {
+	Providers []SearchProvider
+	Timeout   time.Duration // per provider
+}
+
+func NewSearchService(providers ...SearchProvider) *SearchService {
+	timeout := defaultSearchTimeout
+	if v := os.Getenv("SEARCH_PROVIDER_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			timeout = d
+		} else {
+			log.Printf("[Search] Invalid SEARCH_PROVIDER_TIMEOUT %q, using %s", v, timeout)
+		}
+	}
+	return &SearchService{Providers: providers, Timeout: timeout}
+}
+
+// ProviderNames lists the registered providers in registration order
+func (s *SearchService) ProviderNames() []string {
+	names := make([]string, len(s.Providers))
+	for i, p := range s.Providers {
+		names[i] = p.ProviderName()
+	}
+	return names
+}
+
+// Search queries the named providers (all when names is empty) concurrently.
+// A provider that fails or exceeds the timeout is reported in Providers and
+// the others' items are still returned.
+func (s *SearchService) Search(query string, names []string) (*SearchResponse, error) {
+	providers, err := s.selectProviders(names)
+	if err != nil {
+		return nil, err
+	}
+
+	type outcome struct {
+		items  []SearchItem
+		status ProviderStatus
+	}
+	outcomes := make([]outcome, len(providers))
+
+	var wg sync.WaitGroup
+	for i, p := range providers {
+		wg.Add(1)
+		go func(i int, p SearchProvider) {
+			defer wg.Done()
+			start := time.Now()
+			items, err := s.searchWithTimeout(p, query)
+			status := ProviderStatus{Provider: p.ProviderName(), TookMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				log.Printf("[Search] %s failed for %q: %v", status.Provider, query, err)
+				status.Error = err.Error()
+			} else {
+				status.OK = true
+				status.Count = len(items)
+			}
+			outcomes[i] = outcome{items, status}
+		}(i, p)
+	}
+	wg.Wait()
+
+	resp := &SearchResponse{Query: query, Items: []SearchItem{}}
+	lists := make([][]SearchItem, len(outcomes))
+	for i, o := range outcomes {
+		resp.Providers = append(resp.Providers, o.status)
+		lists[i] = o.items
+	}
+	// Best matches first across providers; equally good ones stay interleaved
+	ranked := relevance.Rank(interleave(lists), query, func(item SearchItem) []string { return []string{item.Title} })
+	resp.Items = append(resp.Items, ranked...)
+	return resp, nil
+}
+
+// searchWithTimeout abandons a provider after s.Timeout; the scrape itself
+// finishes in the background and still warms the cache for the next search
+func (s *SearchService) searchWithTimeout(p SearchProvider, query string) ([]SearchItem, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout)
+	defer cancel()
+
+	type result struct {
+		items []SearchItem
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		items, err := p.SearchItems(query)
+		done <- result{items, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.items, r.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out after %s", s.Timeout)
+	}
+}
+
+func (s *SearchService) selectProviders(names []string) ([]SearchProvider, error) {
+	if len(names) == 0 {
+		return s.Providers, nil
+	}
+	var selected []SearchProvider
+	for _, name := range names {
+		found := false
+		for _, p := range s.Providers {
+			if strings.EqualFold(p.ProviderName(), name) {
+				selected = append(selected, p)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown provider %q, expected one of %s", name, strings.Join(s.ProviderNames(), ", "))
+		}
+	}
+	return selected, nil
+}
+
+// interleave merges provider lists round-robin so each keeps its own order
+// and no provider buries the others
+func interleave(lists [][]SearchItem) []SearchItem {
+	var merged []SearchItem
+	for i := 0; ; i++ {
+		added := false
+		for _, list := range lists {
+			if i < len(list) {
+				merged = append(merged, list[i])
+				added = true
+			}
+		}
+		if !added {
+			return merged
+		}
+	}
+}
+
+// ProviderName implements SearchProvider
+func (s *KomikuService) ProviderName() string { return catalog.ProviderKomiku }
+
+// SearchItems implements SearchProvider
+func (s *KomikuService) SearchItems(query string) ([]SearchItem, error) {
+	results, err := s.FetchSearch(query)
+	if err != nil {
+		return nil, err
+	}
+	s.catalogManga(results)
+
+	items := make([]SearchItem, 0, len(results))
+	for _, m := range results {
+		items = append(items, SearchItem{
+			Provider: catalog.ProviderKomiku,
+			Kind:     KindManga,
+			Title:    m.Title,
+			Slug:     catalog.SlugFromEndpoint(m.Endpoint),
+			Endpoint: m.Endpoint,
+			Thumb:    m.Thumb,
+			Type:     m.Type,
+			Score:    m.Score,
+		})
+	}
+	return items, nil
+}
+
+// ProviderName implements SearchProvider
+func (s *WinbuService) ProviderName() string { return catalog.ProviderWinbu }
+
+// SearchItems implements SearchProvider
+func (s *WinbuService) SearchItems(query string) ([]SearchItem, error) {
+	results, err := s.FetchSearch(query)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]SearchItem, 0, len(results))
+	for _, a := range results {
+		kind := KindAnime
+		if strings.EqualFold(a.Type, "movie") || strings.Contains(a.Endpoint, "/film/") {
+			kind = KindMovie
+		}
+		items = append(items, SearchItem{
+			Provider: catalog.ProviderWinbu,
+			Kind:     kind,
+			Title:    a.Title,
+			Slug:     catalog.SlugFromEndpoint(a.Endpoint),
+			Endpoint: a.Endpoint,
+			Thumb:    a.Thumb,
+			Type:     a.Type,
+			Status:   a.Status,
+			Score:    a.Rating,
+		})
+	}
+	return items, nil
+}
@@ -4,10 +4,14 @@ import (
 	"compress/gzip"
 	"fmt"
 	"io"
+	"komiku-scraper/internal/catalog"
+	"komiku-scraper/internal/relevance"
 	"komiku-scraper/scraper/cache"
+	"komiku-scraper/scraper/common"
 	"komiku-scraper/scraper/komiku"
 	"log"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
@@ -16,14 +20,43 @@ import (
 
 // KomikuService handles data fetching logic
 type KomikuService struct {
-	Client *komiku.KomikuClient
-	Cache  *cache.Cache
+	Client  *komiku.KomikuClient
+	Cache   *cache.Cache
+	Catalog *catalog.Store // optional, fed with every parsed detail and browse page
+
+	fresh bool // skip cache reads, see Fresh
 }
 
 func NewKomikuService(client *komiku.KomikuClient, c *cache.Cache) *KomikuService {
 	return &KomikuService{Client: client, Cache: c}
 }
 
+// Background returns a service sharing this one's cache and catalogue whose
+// requests yield to interactive ones at the upstream limiter. Crawls, polls,
+// cache warming and downloads use it.
+func (s *KomikuService) Background() *KomikuService {
+	return &KomikuService{
+		Client:  &komiku.KomikuClient{BaseClient: s.Client.WithPriority(common.PriorityBackground)},
+		Cache:   s.Cache,
+		Catalog: s.Catalog,
+	}
+}
+
+// Fresh returns a copy of the service that skips cache reads on detail, home,
+// ranking and genre fetches but still stores what it fetches. A failed fetch
+// leaves the cached copy in place.
+func (s *KomikuService) Fresh() *KomikuService {
+	return &KomikuService{Client: s.Client, Cache: s.Cache, Catalog: s.Catalog, fresh: true}
+}
+
+// cached looks key up unless the service is fresh
+func (s *KomikuService) cached(key string) (interface{}, bool) {
+	if s.fresh {
+		return nil, false
+	}
+	return s.Cache.Get(key)
+}
+
 func (s *KomikuService) FetchAndParseList(url string) ([]komiku.Manga, error) {
 	cacheKey := fmt.Sprintf(cache.KomikuSearchKey, url)
 	if val, found := s.Cache.Get(cacheKey); found {
@@ -98,9 +131,54 @@ func (s *KomikuService) FetchAndParseList(url string) ([]komiku.Manga, error) {
 	return result, err
 }
 
+// FetchSearch searches komiku by title. Noise words ("baca", "sub indo") are
+// dropped; when nothing is found the show's other known titles are tried,
+// then the local catalogue, so typos and English titles still match.
+// Results are ranked by relevance to the query.
+func (s *KomikuService) FetchSearch(query string) ([]komiku.Manga, error) {
+	query = relevance.CleanQuery(query)
+
+	var (
+		results  []komiku.Manga
+		firstErr error
+	)
+	for _, variant := range relevance.Variants(query) {
+		// The api subdomain with post_type returns the same cards as the site search
+		found, err := s.FetchAndParseList("https://api.komiku.org/?post_type=manga&s=" + url.QueryEscape(variant))
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if len(found) > 0 {
+			results = found
+			break
+		}
+	}
+
+	if len(results) == 0 {
+		matches, err := s.Catalog.Search(catalog.Query{Text: query, Provider: catalog.ProviderKomiku})
+		if err != nil {
+			log.Printf("[Komiku] Catalogue search failed for %q: %v", query, err)
+		} else if len(matches.Items) > 0 {
+			log.Printf("[Komiku] No site results for %q, using %d catalogue matches", query, len(matches.Items))
+			for _, e := range matches.Items {
+				results = append(results, e.Manga())
+			}
+		}
+	}
+	if len(results) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+
+	// Rank copies, the listed slice is shared with the cache
+	return relevance.Rank(results, query, func(m komiku.Manga) []string { return []string{m.Title} }), nil
+}
+
 func (s *KomikuService) FetchAndParseDetail(url string) (*komiku.MangaDetail, error) {
 	cacheKey := fmt.Sprintf(cache.KomikuDetailKey, url)
-	if val, found := s.Cache.Get(cacheKey); found {
+	if val, found := s.cached(cacheKey); found {
 		log.Printf("[Komiku] Cache HIT for detail: %s", url)
 		return val.(*komiku.MangaDetail), nil
 	}
@@ -115,6 +193,13 @@ func (s *KomikuService) FetchAndParseDetail(url string) (*komiku.MangaDetail, er
 	defer resp.Body.Close()
 
 	log.Printf("[Komiku] Detail response status: %d", resp.StatusCode)
+	// Block and error pages parse to a detail without chapters
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrSeriesNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("detail page returned status %d", resp.StatusCode)
+	}
 
 	doc, err := goquery.NewDocumentFromReader(resp.Body)
 	if err != nil {
@@ -126,12 +211,21 @@ func (s *KomikuService) FetchAndParseDetail(url string) (*komiku.MangaDetail, er
 	if err == nil && result != nil {
 		log.Printf("[Komiku] Successfully parsed manga: %s (%d chapters)", result.Title, len(result.Chapters))
 		s.Cache.Set(cacheKey, result, cache.DetailTTL)
+		if err := s.Catalog.Upsert(catalog.FromMangaDetail(url, result)); err != nil {
+			log.Printf("[Komiku] Catalogue update failed for %s: %v", url, err)
+		}
 	}
 	return result, err
 }
 
+// RefreshDetail fetches a detail page past the cache and replaces the cached
+// copy only if the fetch succeeds
+func (s *KomikuService) RefreshDetail(url string) (*komiku.MangaDetail, error) {
+	return s.Fresh().FetchAndParseDetail(url)
+}
+
 func (s *KomikuService) FetchHomeData() (*komiku.HomeData, error) {
-	if val, found := s.Cache.Get(cache.KomikuHomeKey); found {
+	if val, found := s.cached(cache.KomikuHomeKey); found {
 		log.Printf("[Komiku] Cache HIT for home data")
 		return val.(*komiku.HomeData), nil
 	}
@@ -155,6 +249,121 @@ func (s *KomikuService) FetchHomeData() (*komiku.HomeData, error) {
 	return result, err
 }
 
+// FetchRanking loads komiku's peringkat list for a period and optional comic
+// type. When the ranking page is unavailable it falls back to the home page
+// trending box, filtered by type, and marks the result as a fallback. Only
+// real rankings are cached under the period/type key; a failure is
+// remembered for FailureTTL so requests in the meantime go straight to the
+// fallback.
+func (s *KomikuService) FetchRanking(period, comicType string) (*komiku.Ranking, error) {
+	cacheKey := fmt.Sprintf(cache.KomikuRankingKey, period, comicType)
+	if val, found := s.cached(cacheKey); found {
+		log.Printf("[Komiku] Cache HIT for ranking: %s/%s", period, comicType)
+		return val.(*komiku.Ranking), nil
+	}
+
+	ranking := &komiku.Ranking{Period: period, Type: comicType}
+	downKey := fmt.Sprintf(cache.KomikuRankingDownKey, period, comicType)
+	if _, down := s.cached(downKey); down {
+		log.Printf("[Komiku] Ranking page failed recently, serving home trending: %s/%s", period, comicType)
+	} else {
+		items, err := s.fetchRankingPage(komiku.RankingURL(period, comicType))
+		if err == nil && len(items) > 0 {
+			ranking.Items = items
+			s.Cache.Set(cacheKey, ranking, cache.HomeTTL)
+			s.Cache.Delete(downKey)
+			return ranking, nil
+		}
+		log.Printf("[Komiku] Ranking page unavailable (%v), falling back to home trending", err)
+		s.Cache.Set(downKey, true, cache.FailureTTL)
+	}
+
+	home, homeErr := s.FetchHomeData()
+	if homeErr != nil {
+		return nil, homeErr
+	}
+	ranking.Fallback = true
+	ranking.Items = []komiku.Manga{}
+	for _, m := range home.Trending {
+		if comicType == "" || strings.EqualFold(m.Type, comicType) {
+			ranking.Items = append(ranking.Items, m)
+		}
+	}
+	return ranking, nil
+}
+
+// FetchBrowse loads one page of the filterable komiku listing
+func (s *KomikuService) FetchBrowse(filter komiku.BrowseFilter) (*komiku.BrowseResult, error) {
+	url := komiku.BrowseURL(filter)
+	cacheKey := fmt.Sprintf(cache.KomikuBrowseKey, url)
+	if val, found := s.Cache.Get(cacheKey); found {
+		log.Printf("[Komiku] Cache HIT for browse: %s", url)
+		return val.(*komiku.BrowseResult), nil
+	}
+
+	log.Printf("[Komiku] Fetching browse page: %s", url)
+	req, _ := http.NewRequest("GET", url, nil)
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Past the last page komiku answers 404
+	if resp.StatusCode == http.StatusNotFound {
+		return &komiku.BrowseResult{Items: []komiku.Manga{}, Page: filter.Page}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("browse page returned status %d", resp.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := komiku.ParseBrowse(doc, filter.Page)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[Komiku] Browse page %d: %d manga, has next: %v", result.Page, len(result.Items), result.HasNext)
+	s.Cache.Set(cacheKey, result, cache.SearchTTL)
+	s.catalogManga(result.Items)
+	return result, nil
+}
+
+// catalogManga records list cards in the catalogue; detail fetches fill in
+// the rest later
+func (s *KomikuService) catalogManga(items []komiku.Manga) {
+	entries := make([]catalog.Entry, 0, len(items))
+	for _, m := range items {
+		entries = append(entries, catalog.FromManga(m))
+	}
+	if err := s.Catalog.Upsert(entries...); err != nil {
+		log.Printf("[Komiku] Catalogue update failed: %v", err)
+	}
+}
+
+func (s *KomikuService) fetchRankingPage(url string) ([]komiku.Manga, error) {
+	log.Printf("[Komiku] Fetching ranking from: %s", url)
+	req, _ := http.NewRequest("GET", url, nil)
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ranking page returned status %d", resp.StatusCode)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return komiku.ParseRanking(doc)
+}
+
 func (s *KomikuService) FetchChapterImages(url string) ([]komiku.ChapterImage, error) {
 	cacheKey := fmt.Sprintf(cache.KomikuChapterKey, url)
 	if val, found := s.Cache.Get(cacheKey); found {
@@ -209,6 +418,11 @@ func (s *KomikuService) FetchRecommendations(url string) ([]komiku.Manga, error)
 }
 
 func (s *KomikuService) FetchGenreList() ([]komiku.Genre, error) {
+	if val, found := s.cached(cache.KomikuGenresKey); found {
+		log.Printf("[Komiku] Cache HIT for genres")
+		return val.([]komiku.Genre), nil
+	}
+
 	req, _ := http.NewRequest("GET", "https://komiku.org/", nil)
 	resp, err := s.Client.Do(req)
 	if err != nil {
@@ -221,5 +435,9 @@ func (s *KomikuService) FetchGenreList() ([]komiku.Genre, error) {
 		return nil, err
 	}
 
-	return komiku.ParseGenreList(doc)
+	result, err := komiku.ParseGenreList(doc)
+	if err == nil && len(result) > 0 {
+		s.Cache.Set(cache.KomikuGenresKey, result, cache.GenreTTL)
+	}
+	return result, err
 }
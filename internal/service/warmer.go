@@ -0,0 +1,174 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"komiku-scraper/internal/analytics"
+	"komiku-scraper/internal/catalog"
+	"komiku-scraper/scraper/komiku"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Request paths of the detail endpoints, as recorded by analytics
+const (
+	komikuDetailPath = "/api/v1/komiku/manga/"
+	winbuDetailPath  = "/api/v1/winbu/detail/"
+)
+
+// popularWindow is how far back detail requests are counted
+const popularWindow = 7 * 24 * time.Hour
+
+// CacheWarmer refetches cached pages before users find them expired. Each
+// method fetches past the cache and replaces an entry only when its fetch
+// succeeds, so the next request is served from a fresh cache entry instead of
+// waiting on the source site, and an outage keeps the last good copy.
+type CacheWarmer struct {
+	Komiku    *KomikuService
+	Winbu     *WinbuService
+	Analytics *analytics.Analytics // optional, source of the popular details
+	TopN      int                  // details warmed per provider
+}
+
+// NewCacheWarmer reads WARM_TOP_DETAILS (default 20)
+func NewCacheWarmer(komikuSvc *KomikuService, winbuSvc *WinbuService, stats *analytics.Analytics) *CacheWarmer {
+	topN := 20
+	if v, err := strconv.Atoi(os.Getenv("WARM_TOP_DETAILS")); err == nil && v >= 0 {
+		topN = v
+	}
+	return &CacheWarmer{Komiku: komikuSvc, Winbu: winbuSvc, Analytics: stats, TopN: topN}
+}
+
+// Home refreshes both home pages and the winbu lists derived from them
+func (w *CacheWarmer) Home() error {
+	_, komikuErr := w.Komiku.Fresh().FetchHomeData()
+	_, winbuErr := w.Winbu.Fresh().FetchDrama()
+
+	return joinErrors("komiku home", komikuErr, "winbu home", winbuErr)
+}
+
+// Genres refreshes both genre lists
+func (w *CacheWarmer) Genres() error {
+	_, komikuErr := w.Komiku.Fresh().FetchGenreList()
+	_, winbuErr := w.Winbu.Fresh().FetchGenres()
+
+	return joinErrors("komiku genres", komikuErr, "winbu genres", winbuErr)
+}
+
+// Trending refreshes the komiku rankings of every period, all types
+func (w *CacheWarmer) Trending() error {
+	var errs []error
+	fresh := w.Komiku.Fresh()
+	for _, period := range []string{komiku.PeriodDaily, komiku.PeriodWeekly, komiku.PeriodAllTime} {
+		ranking, err := fresh.FetchRanking(period, "")
+		if err == nil && ranking.Fallback {
+			err = errors.New("ranking page unavailable")
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s ranking: %w", period, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PopularDetails refreshes the TopN most requested detail pages of each
+// provider over the last week
+func (w *CacheWarmer) PopularDetails() error {
+	if w.Analytics == nil || w.TopN == 0 {
+		return nil
+	}
+	since := time.Now().Add(-popularWindow)
+	komikuPaths, err := w.Analytics.TopPaths(komikuDetailPath, since, w.TopN)
+	if err != nil {
+		return err
+	}
+	winbuPaths, err := w.Analytics.TopPaths(winbuDetailPath, since, w.TopN)
+	if err != nil {
+		return err
+	}
+
+	type target struct{ provider, slug string }
+	var targets []target
+	for _, p := range komikuPaths {
+		if slug := slugAfter(p.Path, komikuDetailPath); slug != "" {
+			targets = append(targets, target{catalog.ProviderKomiku, slug})
+		}
+	}
+	for _, p := range winbuPaths {
+		if slug := slugAfter(p.Path, winbuDetailPath); slug != "" {
+			targets = append(targets, target{catalog.ProviderWinbu, slug})
+		}
+	}
+
+	var (
+		mu     sync.Mutex
+		failed []error
+		wg     sync.WaitGroup
+	)
+	sem := make(chan struct{}, detailFetchers)
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t target) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var err error
+			if t.provider == catalog.ProviderKomiku {
+				// Same URL as the detail handler, so both share a cache entry
+				_, err = w.Komiku.RefreshDetail("https://komiku.id/manga/" + t.slug + "/")
+			} else {
+				err = w.refreshWinbu(t.slug)
+			}
+			if err != nil {
+				mu.Lock()
+				failed = append(failed, fmt.Errorf("%s/%s: %w", t.provider, t.slug, err))
+				mu.Unlock()
+			}
+		}(t)
+	}
+	wg.Wait()
+
+	log.Printf("[Warmer] Refreshed %d of %d popular details", len(targets)-len(failed), len(targets))
+	return errors.Join(failed...)
+}
+
+// refreshWinbu refreshes whichever of /anime/ and /film/ the slug lives
+// under, trying them in the detail handler's order
+func (w *CacheWarmer) refreshWinbu(slug string) error {
+	detail, err := w.Winbu.RefreshDetail("https://winbu.net/anime/" + slug + "/")
+	if err == nil && detail.Title != "" {
+		return nil
+	}
+	_, err = w.Winbu.RefreshDetail("https://winbu.net/film/" + slug + "/")
+	return err
+}
+
+// slugAfter returns the unescaped path segment following prefix
+func slugAfter(path, prefix string) string {
+	rest := strings.Trim(strings.TrimPrefix(path, prefix), "/")
+	if rest == "" || strings.Contains(rest, "/") {
+		return ""
+	}
+	slug, err := url.PathUnescape(rest)
+	if err != nil {
+		return ""
+	}
+	return slug
+}
+
+func joinErrors(firstName string, first error, secondName string, second error) error {
+	var errs []error
+	if first != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", firstName, first))
+	}
+	if second != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", secondName, second))
+	}
+	return errors.Join(errs...)
+}
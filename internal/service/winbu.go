@@ -4,26 +4,64 @@ import (
 	"compress/gzip"
 	"fmt"
 	"io"
+	"komiku-scraper/internal/catalog"
+	"komiku-scraper/internal/relevance"
+	"komiku-scraper/internal/subtitle"
 	"komiku-scraper/scraper/cache"
+	"komiku-scraper/scraper/common"
 	"komiku-scraper/scraper/winbu"
 	"log"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/andybalholm/brotli"
 )
 
 type WinbuService struct {
-	Client *winbu.WinbuClient
-	Cache  *cache.Cache
+	Client  *winbu.WinbuClient
+	Cache   *cache.Cache
+	Catalog *catalog.Store // optional, fed with every parsed detail and crawl
+
+	crawling atomic.Bool // a catalogue crawl is running
+	fresh    bool        // skip cache reads, see Fresh
 }
 
 func NewWinbuService(client *winbu.WinbuClient, c *cache.Cache) *WinbuService {
 	return &WinbuService{Client: client, Cache: c}
 }
 
+// Background returns a service sharing this one's cache and catalogue whose
+// requests yield to interactive ones at the upstream limiter. Its crawl
+// guard is its own; crawl through the service the handlers use.
+func (s *WinbuService) Background() *WinbuService {
+	return &WinbuService{
+		Client:  &winbu.WinbuClient{BaseClient: s.Client.WithPriority(common.PriorityBackground)},
+		Cache:   s.Cache,
+		Catalog: s.Catalog,
+	}
+}
+
+// Fresh returns a copy of the service that skips cache reads on detail, home,
+// drama and genre fetches but still stores what it fetches. A failed fetch
+// leaves the cached copy in place. Like Background, its crawl guard is its own.
+func (s *WinbuService) Fresh() *WinbuService {
+	return &WinbuService{Client: s.Client, Cache: s.Cache, Catalog: s.Catalog, fresh: true}
+}
+
+// cached looks key up unless the service is fresh
+func (s *WinbuService) cached(key string) (interface{}, bool) {
+	if s.fresh {
+		return nil, false
+	}
+	return s.Cache.Get(key)
+}
+
 // decompressResponse handles brotli/gzip decompression based on Content-Encoding header
 func decompressResponse(resp *http.Response) (io.Reader, error) {
 	contentEncoding := resp.Header.Get("Content-Encoding")
@@ -41,16 +79,59 @@ func decompressResponse(resp *http.Response) (io.Reader, error) {
 	}
 }
 
+// FetchSearch searches winbu by title. Noise words ("nonton", "sub indo") are
+// dropped; when nothing is found the show's other known titles are tried,
+// then the local catalogue, so typos and English titles still match.
+// Results are ranked by relevance to the query.
 func (s *WinbuService) FetchSearch(keyword string) ([]winbu.Anime, error) {
+	keyword = relevance.CleanQuery(keyword)
+
+	var (
+		results  []winbu.Anime
+		firstErr error
+	)
+	for _, variant := range relevance.Variants(keyword) {
+		found, err := s.fetchSearchPage(variant)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if len(found) > 0 {
+			results = found
+			break
+		}
+	}
+
+	if len(results) == 0 {
+		matches, err := s.Catalog.Search(catalog.Query{Text: keyword, Provider: catalog.ProviderWinbu})
+		if err != nil {
+			log.Printf("[Winbu] Catalogue search failed for %q: %v", keyword, err)
+		} else if len(matches.Items) > 0 {
+			log.Printf("[Winbu] No site results for %q, using %d catalogue matches", keyword, len(matches.Items))
+			for _, e := range matches.Items {
+				results = append(results, e.Anime())
+			}
+		}
+	}
+	if len(results) == 0 && firstErr != nil {
+		return nil, firstErr
+	}
+
+	// Rank copies, the page slice is shared with the cache
+	return relevance.Rank(results, keyword, func(a winbu.Anime) []string { return []string{a.Title} }), nil
+}
+
+// fetchSearchPage runs one site search as typed
+func (s *WinbuService) fetchSearchPage(keyword string) ([]winbu.Anime, error) {
 	cacheKey := fmt.Sprintf(cache.WinbuSearchKey, keyword)
 	if val, found := s.Cache.Get(cacheKey); found {
 		log.Printf("[Winbu] Cache HIT for search: %s", keyword)
 		return val.([]winbu.Anime), nil
 	}
 
-	// Winbu search URL: https://winbu.net/?s=keyword
-	// Replace space with +
-	req, _ := http.NewRequest("GET", "https://winbu.net/?s="+keyword, nil)
+	req, _ := http.NewRequest("GET", "https://winbu.net/?s="+url.QueryEscape(keyword), nil)
 	resp, err := s.Client.Do(req)
 	if err != nil {
 		return nil, err
@@ -77,7 +158,7 @@ func (s *WinbuService) FetchSearch(keyword string) ([]winbu.Anime, error) {
 
 func (s *WinbuService) FetchAndParseDetail(url string) (*winbu.AnimeDetail, error) {
 	cacheKey := fmt.Sprintf(cache.WinbuDetailKey, url)
-	if val, found := s.Cache.Get(cacheKey); found {
+	if val, found := s.cached(cacheKey); found {
 		log.Printf("[Winbu] Cache HIT for detail: %s", url)
 		return val.(*winbu.AnimeDetail), nil
 	}
@@ -93,6 +174,14 @@ func (s *WinbuService) FetchAndParseDetail(url string) (*winbu.AnimeDetail, erro
 	}
 	defer resp.Body.Close()
 
+	// Block and error pages parse to a detail without episodes
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrSeriesNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("detail page returned status %d", resp.StatusCode)
+	}
+
 	// Handle compression
 	reader, err := decompressResponse(resp)
 	if err != nil {
@@ -112,20 +201,47 @@ func (s *WinbuService) FetchAndParseDetail(url string) (*winbu.AnimeDetail, erro
 	// If no episodes found (e.g. Movies), use the current page as the episode
 	if len(result.Episodes) == 0 {
 		result.Episodes = append(result.Episodes, winbu.Episode{
-			Title:    "Full Movie / Watch",
-			Endpoint: url,
+			Title:      "Full Movie / Watch",
+			Endpoint:   url,
+			Kind:       winbu.KindMovie,
+			SeriesSlug: winbu.SeriesSlugFromURL(url),
 		})
 	}
 
 	s.Cache.Set(cacheKey, result, cache.DetailTTL)
+	if err := s.Catalog.Upsert(catalog.FromAnimeDetail(url, result)); err != nil {
+		log.Printf("[Winbu] Catalogue update failed for %s: %v", url, err)
+	}
 	return result, nil
 }
 
+// FetchDetailBySlug loads an anime or film page from its slug alone: series
+// live under /anime/, movies under /film/. It returns the URL that worked.
+func (s *WinbuService) FetchDetailBySlug(slug string) (string, *winbu.AnimeDetail, error) {
+	url := "https://winbu.net/anime/" + slug + "/"
+	detail, err := s.FetchAndParseDetail(url)
+	if err == nil && detail.Title != "" {
+		return url, detail, nil
+	}
+	url = "https://winbu.net/film/" + slug + "/"
+	detail, err = s.FetchAndParseDetail(url)
+	if err != nil {
+		return "", nil, err
+	}
+	return url, detail, nil
+}
+
+// RefreshDetail fetches a detail page past the cache and replaces the cached
+// copy only if the fetch succeeds
+func (s *WinbuService) RefreshDetail(url string) (*winbu.AnimeDetail, error) {
+	return s.Fresh().FetchAndParseDetail(url)
+}
+
 // FetchDrama gets latest drama/donghua listings
 func (s *WinbuService) FetchDrama() (interface{}, error) {
-	cacheKey := "winbu:drama"
+	cacheKey := cache.WinbuDramaKey
 
-	if cached, found := s.Cache.Get(cacheKey); found {
+	if cached, found := s.cached(cacheKey); found {
 		log.Println("[Winbu] Cache HIT for drama")
 		return cached, nil
 	}
@@ -145,9 +261,9 @@ func (s *WinbuService) FetchDrama() (interface{}, error) {
 
 // FetchGenres gets all genre listings
 func (s *WinbuService) FetchGenres() (interface{}, error) {
-	cacheKey := "winbu:genres"
+	cacheKey := cache.WinbuGenresKey
 
-	if cached, found := s.Cache.Get(cacheKey); found {
+	if cached, found := s.cached(cacheKey); found {
 		log.Println("[Winbu] Cache HIT for genres")
 		return cached, nil
 	}
@@ -195,15 +311,30 @@ func (s *WinbuService) FetchEpisode(url string) (*winbu.EpisodePageData, error)
 	}
 
 	result, err := winbu.ParseEpisodePage(doc)
-	if err == nil {
-		s.Cache.Set(cacheKey, result, cache.ChapterTTL)
+	if err != nil {
+		return nil, err
 	}
-	return result, err
+
+	// Pages without a canonical link: fall back to the URL we fetched
+	if result.SeriesSlug == "" {
+		result.SeriesSlug = winbu.SeriesSlugFromURL(url)
+		for i := range result.AllEpisodes {
+			result.AllEpisodes[i].SeriesSlug = result.SeriesSlug
+		}
+	}
+	if result.EpisodeNumber == "" {
+		if kind, number := winbu.ParseEpisodeNumber(winbu.SlugFromURL(url)); number != "" {
+			result.EpisodeKind, result.EpisodeNumber = kind, number
+		}
+	}
+
+	s.Cache.Set(cacheKey, result, cache.ChapterTTL)
+	return result, nil
 }
 
 // FetchHomeData loads homepage data for top series, latest movies, latest anime, and genres
 func (s *WinbuService) FetchHomeData() (*winbu.HomeData, error) {
-	if val, found := s.Cache.Get(cache.WinbuHomeKey); found {
+	if val, found := s.cached(cache.WinbuHomeKey); found {
 		log.Printf("[Winbu] Cache HIT for home data")
 		return val.(*winbu.HomeData), nil
 	}
@@ -233,6 +364,167 @@ func (s *WinbuService) FetchHomeData() (*winbu.HomeData, error) {
 	return result, err
 }
 
+// FetchSchedule loads winbu's weekly airing schedule
+func (s *WinbuService) FetchSchedule() ([]winbu.ScheduleDay, error) {
+	if val, found := s.Cache.Get(cache.WinbuScheduleKey); found {
+		log.Printf("[Winbu] Cache HIT for schedule")
+		return val.([]winbu.ScheduleDay), nil
+	}
+
+	req, _ := http.NewRequest("GET", winbu.ScheduleURL, nil)
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("schedule page returned status %d", resp.StatusCode)
+	}
+
+	reader, err := decompressResponse(resp)
+	if err != nil {
+		return nil, fmt.Errorf("decompression error: %v", err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := winbu.ParseSchedule(doc)
+	if err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("no schedule found on %s", winbu.ScheduleURL)
+	}
+
+	s.Cache.Set(cache.WinbuScheduleKey, result, cache.HomeTTL)
+	return result, nil
+}
+
+// FetchIndex loads one page of the anime/film archive or the A-Z list
+func (s *WinbuService) FetchIndex(filter winbu.IndexFilter) (*winbu.IndexPage, error) {
+	url := winbu.IndexURL(filter)
+	cacheKey := fmt.Sprintf(cache.WinbuIndexKey, url)
+	if val, found := s.Cache.Get(cacheKey); found {
+		log.Printf("[Winbu] Cache HIT for index: %s", url)
+		return val.(*winbu.IndexPage), nil
+	}
+
+	req, _ := http.NewRequest("GET", url, nil)
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Past the last page WordPress answers 404
+	if resp.StatusCode == http.StatusNotFound {
+		return &winbu.IndexPage{Items: []winbu.Anime{}, Page: filter.Page}, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("index page returned status %d", resp.StatusCode)
+	}
+
+	reader, err := decompressResponse(resp)
+	if err != nil {
+		return nil, fmt.Errorf("decompression error: %v", err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := winbu.ParseIndex(doc, filter.Page)
+	if err != nil {
+		return nil, err
+	}
+	s.Cache.Set(cacheKey, result, cache.SearchTTL)
+	return result, nil
+}
+
+const (
+	// maxCrawlPages stops a crawl if pagination detection ever loops
+	maxCrawlPages = 500
+	// crawlDelay spaces out archive requests to stay polite
+	crawlDelay = 500 * time.Millisecond
+)
+
+// ErrCrawlRunning is returned when a catalogue crawl is already in progress
+var ErrCrawlRunning = fmt.Errorf("catalogue crawl already running")
+
+// Crawling reports whether a catalogue crawl is in progress
+func (s *WinbuService) Crawling() bool {
+	return s.crawling.Load()
+}
+
+// CachedCatalogue returns the last crawled catalogue, if still cached
+func (s *WinbuService) CachedCatalogue() ([]winbu.Anime, bool) {
+	if val, found := s.Cache.Get(cache.WinbuCatalogueKey); found {
+		return val.([]winbu.Anime), true
+	}
+	return nil, false
+}
+
+// CrawlCatalogue walks the anime and film archives page by page and returns
+// every series and movie once, sorted by title
+func (s *WinbuService) CrawlCatalogue() ([]winbu.Anime, error) {
+	if !s.crawling.CompareAndSwap(false, true) {
+		return nil, ErrCrawlRunning
+	}
+	defer s.crawling.Store(false)
+
+	start := time.Now()
+	seen := make(map[string]bool)
+	var catalogue []winbu.Anime
+	background := s.Background()
+
+	for _, kind := range []string{winbu.IndexAnime, winbu.IndexFilm} {
+		for page := 1; page <= maxCrawlPages; page++ {
+			if page > 1 {
+				time.Sleep(crawlDelay)
+			}
+			result, err := background.FetchIndex(winbu.IndexFilter{Kind: kind, Page: page})
+			if err != nil {
+				if page == 1 {
+					return nil, fmt.Errorf("crawl %s archive: %v", kind, err)
+				}
+				log.Printf("[Winbu] Crawl of %s stopped at page %d: %v", kind, page, err)
+				break
+			}
+			for _, a := range result.Items {
+				if !seen[a.Endpoint] {
+					seen[a.Endpoint] = true
+					catalogue = append(catalogue, a)
+				}
+			}
+			if !result.HasNext || len(result.Items) == 0 {
+				log.Printf("[Winbu] Crawled %d pages of %s archive", page, kind)
+				break
+			}
+		}
+	}
+
+	sort.Slice(catalogue, func(i, j int) bool {
+		return strings.ToLower(catalogue[i].Title) < strings.ToLower(catalogue[j].Title)
+	})
+
+	log.Printf("[Winbu] Catalogue crawl finished: %d titles in %s", len(catalogue), time.Since(start).Round(time.Second))
+	s.Cache.Set(cache.WinbuCatalogueKey, catalogue, cache.CatalogueTTL)
+
+	entries := make([]catalog.Entry, 0, len(catalogue))
+	for _, a := range catalogue {
+		entries = append(entries, catalog.FromAnime(a))
+	}
+	if err := s.Catalog.Upsert(entries...); err != nil {
+		log.Printf("[Winbu] Catalogue update failed: %v", err)
+	}
+	return catalogue, nil
+}
+
 func (s *WinbuService) ResolveStream(opt winbu.StreamOption) (string, error) {
 	data := url.Values{}
 	data.Set("action", "player_ajax")
@@ -336,6 +628,281 @@ func (s *WinbuService) ResolveStream(opt winbu.StreamOption) (string, error) {
 	return "", fmt.Errorf("no iframe src found in response after trying all strategies")
 }
 
+// fetchEmbed implements winbu.Fetcher using the winbu client
+func (s *WinbuService) fetchEmbed(pageURL, referer string) ([]byte, error) {
+	return s.fetchEmbedLimit(pageURL, referer, 0)
+}
+
+// fetchEmbedLimit is fetchEmbed reading at most limit bytes of the body when
+// limit is positive; a longer body is cut at limit+1 bytes so callers can
+// tell it was too large without buffering all of it
+func (s *WinbuService) fetchEmbedLimit(pageURL, referer string, limit int64) ([]byte, error) {
+	req, err := http.NewRequest("GET", pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if referer == "" {
+		referer = "https://winbu.net/"
+	}
+	req.Header.Set("Referer", referer)
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("embed host returned status %d", resp.StatusCode)
+	}
+
+	reader, err := decompressResponse(resp)
+	if err != nil {
+		return nil, fmt.Errorf("decompression error: %v", err)
+	}
+	if limit > 0 {
+		reader = io.LimitReader(reader, limit+1)
+	}
+	return io.ReadAll(reader)
+}
+
+// ResolveMedia resolves a stream option to its iframe and extracts direct
+// media sources from the embed host. On extraction failure the returned
+// result may still hold the subtitles found on the embed page.
+func (s *WinbuService) ResolveMedia(opt winbu.StreamOption) (*winbu.EmbedResult, error) {
+	cacheKey := fmt.Sprintf(cache.WinbuStreamKey, opt.PostID+":"+opt.Nume+":"+opt.Type)
+	if val, found := s.Cache.Get(cacheKey); found {
+		log.Printf("[Winbu] Cache HIT for stream: %s", cacheKey)
+		return val.(*winbu.EmbedResult), nil
+	}
+
+	embedURL, err := s.ResolveStream(opt)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := winbu.ExtractEmbed(embedURL, s.fetchEmbed)
+	if result == nil {
+		return nil, err
+	}
+	for i := range result.Sources {
+		result.Sources[i].Server = opt.Server
+	}
+	for i := range result.Subtitles {
+		result.Subtitles[i].Server = opt.Server
+	}
+	s.rememberSubtitles(result, embedURL)
+	if err != nil {
+		// Partial result: subtitles without a playable source, not cached
+		return result, err
+	}
+
+	log.Printf("[Winbu] Extracted %d media sources from %s", len(result.Sources), embedURL)
+	s.Cache.Set(cacheKey, result, cache.StreamTTL)
+	return result, nil
+}
+
+// ResolveEpisodeMedia resolves every stream option of an episode concurrently
+// and returns a copy of data with Sources and Subtitles filled. Failing
+// servers contribute only the subtitles they exposed.
+func (s *WinbuService) ResolveEpisodeMedia(data *winbu.EpisodePageData) *winbu.EpisodePageData {
+	resolved := *data
+	resolved.Sources = nil
+	resolved.Subtitles = nil
+
+	results := make([]*winbu.EmbedResult, len(data.StreamOptions))
+	var wg sync.WaitGroup
+	for i, opt := range data.StreamOptions {
+		wg.Add(1)
+		go func(i int, opt winbu.StreamOption) {
+			defer wg.Done()
+			result, err := s.ResolveMedia(opt)
+			if err != nil {
+				log.Printf("[Winbu] Could not extract media for %s: %v", opt.Server, err)
+			}
+			// Keep subtitles even when the server had no playable source
+			results[i] = result
+		}(i, opt)
+	}
+	wg.Wait()
+
+	// Keep server order stable regardless of which finished first
+	for _, result := range results {
+		if result != nil {
+			resolved.Sources = append(resolved.Sources, result.Sources...)
+			resolved.Subtitles = append(resolved.Subtitles, result.Subtitles...)
+		}
+	}
+	return &resolved
+}
+
+// maxSubtitleSize caps subtitle downloads; real files are well under 1MB
+const maxSubtitleSize = 5 << 20
+
+// rememberSubtitles records the tracks of a resolved embed so FetchSubtitle
+// only ever fetches URLs an embed host handed out. Entries outlive the
+// stream cache, so tracks of a cached result stay fetchable.
+func (s *WinbuService) rememberSubtitles(result *winbu.EmbedResult, embedURL string) {
+	if strings.HasPrefix(embedURL, "//") {
+		embedURL = "https:" + embedURL
+	}
+	for _, sub := range result.Subtitles {
+		referer := sub.Headers["Referer"]
+		if referer == "" {
+			referer = embedURL
+		}
+		s.Cache.Set(fmt.Sprintf(cache.WinbuSubtitleKey, sub.URL), referer, cache.DetailTTL)
+	}
+}
+
+// ErrUnknownSubtitle is returned for tracks that did not come from a
+// resolved episode
+var ErrUnknownSubtitle = fmt.Errorf("unknown subtitle url, resolve the episode first")
+
+// FetchSubtitle downloads a subtitle track found on a resolved embed page
+// and returns it converted to WebVTT
+func (s *WinbuService) FetchSubtitle(subURL string) ([]byte, error) {
+	val, found := s.Cache.Get(fmt.Sprintf(cache.WinbuSubtitleKey, subURL))
+	if !found {
+		return nil, ErrUnknownSubtitle
+	}
+	u, err := url.Parse(subURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid subtitle url")
+	}
+
+	body, err := s.fetchEmbedLimit(u.String(), val.(string), maxSubtitleSize)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > maxSubtitleSize {
+		return nil, fmt.Errorf("subtitle too large")
+	}
+
+	format := subtitle.DetectFormat(body, winbu.SubtitleFormat(u.Path))
+	return subtitle.ToWebVTT(body, format)
+}
+
+// maxRedirectHops bounds how many shortener pages are followed per link
+const maxRedirectHops = 8
+
+// ResolveDownload follows a download link through shorteners and safelink
+// interstitials until it reaches the file host. HTTP redirects are followed
+// by the client; meta refresh, JS redirects and base64 targets are unwrapped
+// here. Pages on non-shortener hosts are never fetched.
+func (s *WinbuService) ResolveDownload(link winbu.DownloadLink) (winbu.DownloadLink, error) {
+	cacheKey := fmt.Sprintf(cache.WinbuDownloadKey, link.URL)
+	if val, found := s.Cache.Get(cacheKey); found {
+		log.Printf("[Winbu] Cache HIT for download: %s", link.URL)
+		final := val.(string)
+		link.ResolvedURL = final
+		if u, err := url.Parse(final); err == nil {
+			link.Host = u.Host
+		}
+		return link, nil
+	}
+
+	current, err := url.Parse(link.URL)
+	if err != nil {
+		return link, fmt.Errorf("invalid download url: %v", err)
+	}
+
+	for hop := 0; hop < maxRedirectHops; hop++ {
+		if !winbu.IsShortener(current.Host) {
+			break
+		}
+		if target := winbu.UnwrapRedirect(current); target != "" {
+			current, _ = url.Parse(target)
+			continue
+		}
+
+		next, err := s.followShortener(current)
+		if err != nil {
+			return link, err
+		}
+		if next == nil {
+			return link, fmt.Errorf("no redirect found on %s", current.Host)
+		}
+		current = next
+	}
+	if winbu.IsShortener(current.Host) {
+		return link, fmt.Errorf("too many redirects resolving %s", link.URL)
+	}
+
+	link.ResolvedURL = current.String()
+	link.Host = current.Host
+	log.Printf("[Winbu] Resolved download %s -> %s", link.URL, link.ResolvedURL)
+	s.Cache.Set(cacheKey, link.ResolvedURL, cache.DetailTTL)
+	return link, nil
+}
+
+// followShortener fetches one shortener page and returns where it leads,
+// nil when the page has no recognisable redirect
+func (s *WinbuService) followShortener(u *url.URL) (*url.URL, error) {
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// The client already followed any HTTP redirects
+	final := resp.Request.URL
+	if final.String() != u.String() {
+		return final, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", u.Host, resp.StatusCode)
+	}
+
+	reader, err := decompressResponse(resp)
+	if err != nil {
+		return nil, fmt.Errorf("decompression error: %v", err)
+	}
+	body, err := io.ReadAll(io.LimitReader(reader, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	next := winbu.NextHop(final, body)
+	if next == "" {
+		return nil, nil
+	}
+	return url.Parse(next)
+}
+
+// ResolveEpisodeDownloads resolves every download link of an episode
+// concurrently and returns a copy of data with ResolvedURL/Host filled and
+// Downloads regrouped. Links that fail to resolve keep their original URL.
+func (s *WinbuService) ResolveEpisodeDownloads(data *winbu.EpisodePageData) *winbu.EpisodePageData {
+	resolved := *data
+	resolved.DownloadLinks = make([]winbu.DownloadLink, len(data.DownloadLinks))
+
+	sem := make(chan struct{}, 4)
+	var wg sync.WaitGroup
+	for i, link := range data.DownloadLinks {
+		wg.Add(1)
+		go func(i int, link winbu.DownloadLink) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			result, err := s.ResolveDownload(link)
+			if err != nil {
+				log.Printf("[Winbu] Could not resolve download %s: %v", link.URL, err)
+			}
+			resolved.DownloadLinks[i] = result
+		}(i, link)
+	}
+	wg.Wait()
+
+	resolved.Downloads = winbu.GroupDownloads(resolved.DownloadLinks)
+	return &resolved
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
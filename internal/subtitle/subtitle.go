@@ -0,0 +1,193 @@
+package subtitle
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Subtitle formats understood by ToWebVTT
+const (
+	FormatVTT = "vtt"
+	FormatSRT = "srt"
+	FormatASS = "ass"
+)
+
+// DetectFormat sniffs the subtitle format from its content, falling back to
+// the format guessed from the URL/filename
+func DetectFormat(data []byte, fallback string) string {
+	head := strings.TrimSpace(string(normalize(data[:min(len(data), 512)])))
+	switch {
+	case strings.HasPrefix(head, "WEBVTT"):
+		return FormatVTT
+	case strings.HasPrefix(head, "[Script Info]"), strings.Contains(head, "[V4+ Styles]"), strings.Contains(head, "[V4 Styles]"):
+		return FormatASS
+	case strings.Contains(head, "-->"):
+		return FormatSRT
+	}
+	return fallback
+}
+
+// ToWebVTT converts an SRT, ASS/SSA or WebVTT file to WebVTT
+func ToWebVTT(data []byte, format string) ([]byte, error) {
+	text := normalize(data)
+	switch format {
+	case FormatVTT:
+		return text, nil
+	case FormatSRT:
+		return srtToVTT(text), nil
+	case FormatASS:
+		return assToVTT(text)
+	}
+	return nil, fmt.Errorf("unsupported subtitle format %q", format)
+}
+
+// normalize strips the UTF-8 BOM, converts Latin-1 files to UTF-8 and uses
+// \n line endings
+func normalize(data []byte) []byte {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(data) {
+		// Older Indonesian fansub releases are often Windows-1252/Latin-1
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		data = []byte(string(runes))
+	}
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	return bytes.ReplaceAll(data, []byte("\r"), []byte("\n"))
+}
+
+var (
+	srtTimingPattern = regexp.MustCompile(`(\d{1,2}:\d{2}:\d{2}),(\d{1,3})`)
+	srtOverride      = regexp.MustCompile(`\{\\[^}]*\}`) // {\an8} style tags some SRTs carry
+)
+
+// srtToVTT keeps cues as they are: WebVTT accepts SRT's numeric identifiers
+// and <i>/<b>/<u> tags, only the millisecond separator differs
+func srtToVTT(text []byte) []byte {
+	var out bytes.Buffer
+	out.WriteString("WEBVTT\n\n")
+	for _, line := range strings.Split(string(text), "\n") {
+		if strings.Contains(line, "-->") {
+			line = srtTimingPattern.ReplaceAllStringFunc(line, func(ts string) string {
+				m := srtTimingPattern.FindStringSubmatch(ts)
+				return fmt.Sprintf("%08s.%03s", m[1], m[2]) // VTT wants hh:mm:ss.mmm
+			})
+		} else {
+			line = srtOverride.ReplaceAllString(line, "")
+		}
+		out.WriteString(line)
+		out.WriteByte('\n')
+	}
+	return out.Bytes()
+}
+
+type cue struct {
+	start, end float64 // seconds
+	text       string
+}
+
+var assOverride = regexp.MustCompile(`\{[^}]*\}`)
+
+// assToVTT converts the [Events] Dialogue lines; styling and positioning are
+// dropped since browsers render VTT with their own style
+func assToVTT(text []byte) ([]byte, error) {
+	var (
+		cues     []cue
+		inEvents bool
+		fields   []string
+	)
+	for _, line := range strings.Split(string(text), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "[") {
+			inEvents = strings.EqualFold(line, "[Events]")
+			continue
+		}
+		if !inEvents {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Format":
+			fields = strings.Split(value, ",")
+			for i := range fields {
+				fields[i] = strings.TrimSpace(fields[i])
+			}
+		case "Dialogue":
+			if fields == nil {
+				return nil, fmt.Errorf("dialogue before Format line")
+			}
+			// Text is the last field and may itself contain commas
+			parts := strings.SplitN(strings.TrimSpace(value), ",", len(fields))
+			if len(parts) != len(fields) {
+				continue
+			}
+			var c cue
+			for i, name := range fields {
+				switch name {
+				case "Start":
+					c.start = parseASSTime(parts[i])
+				case "End":
+					c.end = parseASSTime(parts[i])
+				case "Text":
+					c.text = assText(parts[i])
+				}
+			}
+			if c.text != "" && c.end > c.start {
+				cues = append(cues, c)
+			}
+		}
+	}
+	if len(cues) == 0 {
+		return nil, fmt.Errorf("no dialogue found in ASS subtitle")
+	}
+
+	// ASS files are not required to be ordered, VTT cues are
+	sort.SliceStable(cues, func(i, j int) bool { return cues[i].start < cues[j].start })
+
+	var out bytes.Buffer
+	out.WriteString("WEBVTT\n\n")
+	for _, c := range cues {
+		fmt.Fprintf(&out, "%s --> %s\n%s\n\n", vttTime(c.start), vttTime(c.end), c.text)
+	}
+	return out.Bytes(), nil
+}
+
+// assText strips override blocks and converts ASS escapes
+func assText(s string) string {
+	s = assOverride.ReplaceAllString(s, "")
+	s = strings.NewReplacer(`\N`, "\n", `\n`, "\n", `\h`, " ").Replace(s)
+	s = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+	// A blank line would end the VTT cue early
+	for strings.Contains(s, "\n\n") {
+		s = strings.ReplaceAll(s, "\n\n", "\n")
+	}
+	return strings.TrimSpace(s)
+}
+
+// parseASSTime parses "h:mm:ss.cc" into seconds
+func parseASSTime(s string) float64 {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) != 3 {
+		return 0
+	}
+	h, _ := strconv.Atoi(parts[0])
+	m, _ := strconv.Atoi(parts[1])
+	sec, _ := strconv.ParseFloat(parts[2], 64)
+	return float64(h*3600+m*60) + sec
+}
+
+// vttTime formats seconds as "hh:mm:ss.mmm"
+func vttTime(t float64) string {
+	ms := int(t*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,111 @@
+package subtitle
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToWebVTT(t *testing.T) {
+	const assHeader = "[Script Info]\nTitle: x\n\n[V4+ Styles]\nFormat: Name, Fontname\nStyle: Default,Arial\n\n[Events]\nFormat: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n"
+
+	tests := []struct {
+		name    string
+		input   string
+		format  string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "webvtt passes through",
+			input:  "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nHalo\n",
+			format: FormatVTT,
+			want:   "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nHalo\n",
+		},
+		{
+			name:   "srt timings and crlf",
+			input:  "1\r\n00:00:01,500 --> 0:00:03,250\r\n<i>Halo</i>\r\n\r\n",
+			format: FormatSRT,
+			want:   "WEBVTT\n\n1\n00:00:01.500 --> 00:00:03.250\n<i>Halo</i>\n\n\n",
+		},
+		{
+			name:   "srt override tags and bom",
+			input:  "\xef\xbb\xbf1\n00:00:01,000 --> 00:00:02,000\n{\\an8}Di atas\n",
+			format: FormatSRT,
+			want:   "WEBVTT\n\n1\n00:00:01.000 --> 00:00:02.000\nDi atas\n\n",
+		},
+		{
+			name:   "latin-1 srt",
+			input:  "1\n00:00:01,000 --> 00:00:02,000\nCaf\xe9\n",
+			format: FormatSRT,
+			want:   "WEBVTT\n\n1\n00:00:01.000 --> 00:00:02.000\nCafé\n\n",
+		},
+		{
+			name: "ass dialogue sorted and cleaned",
+			input: assHeader +
+				"Dialogue: 0,0:00:05.00,0:00:06.50,Default,,0,0,0,,{\\i1}Kedua{\\i0}, lagi\n" +
+				"Dialogue: 0,0:00:01.20,0:00:02.00,Default,,0,0,0,,Satu\\Ndua <tag> & \n",
+			format: FormatASS,
+			want: "WEBVTT\n\n" +
+				"00:00:01.200 --> 00:00:02.000\nSatu\ndua &lt;tag&gt; &amp;\n\n" +
+				"00:00:05.000 --> 00:00:06.500\nKedua, lagi\n\n",
+		},
+		{
+			name:   "ass cues ending before they start are dropped",
+			input:  assHeader + "Dialogue: 0,0:00:03.00,0:00:02.00,Default,,0,0,0,,Mundur\nDialogue: 0,0:00:03.00,0:00:04.00,Default,,0,0,0,,Maju\n",
+			format: FormatASS,
+			want:   "WEBVTT\n\n00:00:03.000 --> 00:00:04.000\nMaju\n\n",
+		},
+		{
+			name:    "ass without dialogue",
+			input:   assHeader,
+			format:  FormatASS,
+			wantErr: true,
+		},
+		{
+			name:    "ass dialogue before format",
+			input:   "[Events]\nDialogue: 0,0:00:01.00,0:00:02.00,Default,,0,0,0,,x\n",
+			format:  FormatASS,
+			wantErr: true,
+		},
+		{
+			name:    "unknown format",
+			input:   "whatever",
+			format:  "sub",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToWebVTT([]byte(tt.input), tt.format)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got:\n%q\nwant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		input, fallback, want string
+	}{
+		{"\xef\xbb\xbfWEBVTT\n\n", FormatSRT, FormatVTT},
+		{"[Script Info]\nTitle: x\n", FormatSRT, FormatASS},
+		{"1\n00:00:01,000 --> 00:00:02,000\nx\n", FormatVTT, FormatSRT},
+		{"garbage", FormatASS, FormatASS},
+	}
+	for _, tt := range tests {
+		if got := DetectFormat([]byte(tt.input), tt.fallback); got != tt.want {
+			t.Errorf("DetectFormat(%q) = %q, want %q", strings.SplitN(tt.input, "\n", 2)[0], got, tt.want)
+		}
+	}
+}
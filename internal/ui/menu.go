@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"komiku-scraper/internal/downloader"
 	"komiku-scraper/internal/service"
+	"komiku-scraper/scraper/komiku"
 	"komiku-scraper/scraper/winbu"
 	"log"
 	"os"
@@ -18,6 +19,8 @@ var dl *downloader.Downloader
 func StartMenu(komikuSvc *service.KomikuService, winbuSvc *service.WinbuService) {
 	// Initialize Downloader
 	dl = downloader.New()
+	// Continue any chapter downloads interrupted by a previous run
+	go dl.ResumeUnfinished()
 
 	scanner := bufio.NewScanner(os.Stdin)
 
@@ -107,25 +110,29 @@ func menuKomiku(svc *service.KomikuService, scanner *bufio.Scanner) {
 
 			case "3": // Manga Trending
 				fmt.Println("Mengambil Data Trending...")
-				homeData, err := svc.FetchHomeData()
+				ranking, err := svc.FetchRanking(komiku.PeriodDaily, "")
 				if err != nil {
 					log.Println("Error:", err)
 					continue
 				}
+				trending := ranking.Items
+				if ranking.Fallback {
+					fmt.Println("(Halaman peringkat tidak tersedia, menampilkan trending beranda)")
+				}
 
 				fmt.Println("\n🔥 Trending / Peringkat:")
-				for i, m := range homeData.Trending {
-					fmt.Printf("#%d %s\n", i+1, m.Title)
+				for i, m := range trending {
+					fmt.Printf("#%d %s (%s)\n", i+1, m.Title, m.Type)
 				}
 
 				// Add selection prompt
-				if len(homeData.Trending) > 0 {
+				if len(trending) > 0 {
 					fmt.Print("\nPilih nomor manga trending untuk detail (0 batal): ")
 					if scanner.Scan() {
 						var sel int
 						fmt.Sscanf(scanner.Text(), "%d", &sel)
-						if sel > 0 && sel <= len(homeData.Trending) {
-							handleDetail(svc, scanner, homeData.Trending[sel-1].Endpoint)
+						if sel > 0 && sel <= len(trending) {
+							handleDetail(svc, scanner, trending[sel-1].Endpoint)
 						}
 					}
 				}
@@ -498,14 +505,18 @@ func handleEpisodeWinbu(svc *service.WinbuService, scanner *bufio.Scanner, anime
 	// Show Download Links
 	if len(epData.DownloadLinks) > 0 {
 		fmt.Println("\nLink Download Langsung:")
-		for _, link := range epData.DownloadLinks {
-			fmt.Printf("- %s [%s]: %s\n", link.Server, link.Quality, link.URL)
+		for _, group := range epData.Downloads {
+			fmt.Println(strings.Join(strings.Fields(group.Format+" "+group.Encoding+" "+group.Quality), " "))
+			for _, link := range group.Links {
+				fmt.Printf("  - %s: %s\n", link.Server, link.URL)
+			}
 		}
 	}
 
 	fmt.Println("\nOpsi:")
 	fmt.Println("1. Streaming (Buka Browser)")
 	fmt.Println("2. Save Info Download (Untuk IDM/XDM Manual)")
+	fmt.Println("3. Download Video (MP4/HLS)")
 	fmt.Println("0. Kembali")
 
 	fmt.Print("Pilih: ")
@@ -547,6 +558,54 @@ func handleEpisodeWinbu(svc *service.WinbuService, scanner *bufio.Scanner, anime
 			if err != nil {
 				fmt.Printf("Error saving info: %v\n", err)
 			}
+		case "3":
+			fmt.Print("Kualitas (contoh: 720p, kosong = terbaik): ")
+			quality := ""
+			if scanner.Scan() {
+				quality = strings.TrimSpace(scanner.Text())
+			}
+			handleVideoDownload(svc, animeTitle, ep.Title, epData, quality)
+		}
+	}
+}
+
+// handleVideoDownload prefers a direct download link and falls back to the
+// media extracted from the matching stream server
+func handleVideoDownload(svc *service.WinbuService, animeTitle, episodeTitle string, epData *winbu.EpisodePageData, quality string) {
+	if len(epData.DownloadLinks) > 0 {
+		fmt.Println("Mengikuti link download...")
+		epData = svc.ResolveEpisodeDownloads(epData)
+	}
+
+	mediaURL := ""
+	if link := downloader.SelectDownloadLink(epData.DownloadLinks, quality); link != nil {
+		mediaURL = downloader.DownloadTarget(*link)
+	} else if opt := downloader.SelectStreamOption(epData.StreamOptions, quality); opt != nil {
+		fmt.Println("Mengambil URL video...")
+		result, err := svc.ResolveMedia(*opt)
+		if err != nil {
+			log.Println("Error resolving stream:", err)
+			return
+		}
+		mediaURL = selectSource(result.Sources, quality).URL
+	}
+
+	if mediaURL == "" || !dl.ProbeDirectMedia(mediaURL) && !strings.Contains(mediaURL, "pixeldrain.com/api/file/") {
+		fmt.Println("Tidak ada URL video langsung (MP4/HLS) untuk episode ini.")
+		return
+	}
+
+	if _, err := dl.DownloadEpisode(animeTitle, episodeTitle, mediaURL, quality); err != nil {
+		fmt.Printf("Error downloading: %v\n", err)
+	}
+}
+
+// selectSource picks the source matching quality, else the first one
+func selectSource(sources []winbu.MediaSource, quality string) winbu.MediaSource {
+	for _, src := range sources {
+		if quality != "" && downloader.QualityHeight(src.Quality) == downloader.QualityHeight(quality) {
+			return src
 		}
 	}
+	return sources[0]
 }
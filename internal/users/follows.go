@@ -0,0 +1,232 @@
+package users
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Follow is a series a user wants new chapters or episodes of
+type Follow struct {
+	Provider  string     `json:"provider"`
+	Series    string     `json:"series"`
+	Title     string     `json:"title"`
+	Items     int        `json:"items"` // chapters/episodes in the last snapshot
+	CreatedAt time.Time  `json:"created_at"`
+	CheckedAt *time.Time `json:"checked_at"` // last poll, nil before the first
+}
+
+// SeriesRef names one series on one provider
+type SeriesRef struct {
+	Provider string
+	Series   string
+}
+
+// Snapshot is the chapter/episode list of a series at its last poll
+type Snapshot struct {
+	Provider  string
+	Series    string
+	Title     string
+	Items     []string // chapter/episode slugs
+	CheckedAt time.Time
+}
+
+// Release is a chapter or episode that appeared between two polls
+type Release struct {
+	ID          int64     `json:"id"`
+	Provider    string    `json:"provider"`
+	Series      string    `json:"series"`
+	SeriesTitle string    `json:"series_title"`
+	Item        string    `json:"item"`
+	ItemTitle   string    `json:"item_title"`
+	Endpoint    string    `json:"endpoint"`
+	DetectedAt  time.Time `json:"detected_at"`
+}
+
+// Times are stored in UTC: go-sqlite3 writes them as text with their zone
+// offset, and the queries below compare them as text.
+const followSchema = `
+CREATE TABLE IF NOT EXISTS follows (
+	user_id INTEGER NOT NULL REFERENCES users(id),
+	provider TEXT NOT NULL,
+	series TEXT NOT NULL,
+	created_at DATETIME NOT NULL,
+	PRIMARY KEY (user_id, provider, series)
+);
+CREATE TABLE IF NOT EXISTS snapshots (
+	provider TEXT NOT NULL,
+	series TEXT NOT NULL,
+	title TEXT NOT NULL DEFAULT '',
+	items TEXT NOT NULL DEFAULT '',
+	checked_at DATETIME NOT NULL,
+	PRIMARY KEY (provider, series)
+);
+CREATE TABLE IF NOT EXISTS releases (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	provider TEXT NOT NULL,
+	series TEXT NOT NULL,
+	item TEXT NOT NULL,
+	item_title TEXT NOT NULL DEFAULT '',
+	endpoint TEXT NOT NULL DEFAULT '',
+	detected_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_releases_series ON releases(provider, series, detected_at);
+`
+
+// Follow adds a series to a user's follow list; following twice is a no-op
+func (s *Store) Follow(userID int64, provider, series string) error {
+	if s == nil {
+		return nil
+	}
+	_, err := s.db.Exec(`INSERT OR IGNORE INTO follows (user_id, provider, series, created_at) VALUES (?, ?, ?, ?)`,
+		userID, provider, series, time.Now().UTC())
+	return err
+}
+
+// Unfollow removes a series from a user's follow list
+func (s *Store) Unfollow(userID int64, provider, series string) error {
+	if s == nil {
+		return nil
+	}
+	_, err := s.db.Exec(`DELETE FROM follows WHERE user_id = ? AND provider = ? AND series = ?`, userID, provider, series)
+	return err
+}
+
+// Follows lists a user's followed series, most recently followed first
+func (s *Store) Follows(userID int64) ([]Follow, error) {
+	follows := []Follow{}
+	if s == nil {
+		return follows, nil
+	}
+	rows, err := s.db.Query(`SELECT f.provider, f.series, COALESCE(sn.title, ''), COALESCE(sn.items, ''), f.created_at, sn.checked_at
+		FROM follows f LEFT JOIN snapshots sn ON sn.provider = f.provider AND sn.series = f.series
+		WHERE f.user_id = ? ORDER BY f.created_at DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var (
+			f         Follow
+			items     string
+			checkedAt sql.NullTime
+		)
+		if err := rows.Scan(&f.Provider, &f.Series, &f.Title, &items, &f.CreatedAt, &checkedAt); err != nil {
+			return nil, err
+		}
+		f.Items = len(splitItems(items))
+		if checkedAt.Valid {
+			f.CheckedAt = &checkedAt.Time
+		}
+		follows = append(follows, f)
+	}
+	return follows, rows.Err()
+}
+
+// FollowedSeries lists every series at least one user follows, least
+// recently polled first
+func (s *Store) FollowedSeries() ([]SeriesRef, error) {
+	refs := []SeriesRef{}
+	if s == nil {
+		return refs, nil
+	}
+	rows, err := s.db.Query(`SELECT DISTINCT f.provider, f.series FROM follows f
+		LEFT JOIN snapshots sn ON sn.provider = f.provider AND sn.series = f.series
+		ORDER BY sn.checked_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r SeriesRef
+		if err := rows.Scan(&r.Provider, &r.Series); err != nil {
+			return nil, err
+		}
+		refs = append(refs, r)
+	}
+	return refs, rows.Err()
+}
+
+// Snapshot returns the last chapter/episode list of a series, or nil before
+// its first poll
+func (s *Store) Snapshot(provider, series string) (*Snapshot, error) {
+	if s == nil {
+		return nil, nil
+	}
+	snap := Snapshot{Provider: provider, Series: series}
+	var items string
+	err := s.db.QueryRow(`SELECT title, items, checked_at FROM snapshots WHERE provider = ? AND series = ?`,
+		provider, series).Scan(&snap.Title, &items, &snap.CheckedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	snap.Items = splitItems(items)
+	return &snap, nil
+}
+
+// SaveSnapshot replaces a series' snapshot and records the releases found
+// by diffing it against the previous one, setting their IDs
+func (s *Store) SaveSnapshot(snap Snapshot, releases []Release) error {
+	if s == nil {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO snapshots (provider, series, title, items, checked_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(provider, series) DO UPDATE SET title = excluded.title, items = excluded.items, checked_at = excluded.checked_at`,
+		snap.Provider, snap.Series, snap.Title, strings.Join(snap.Items, "\n"), snap.CheckedAt.UTC())
+	if err != nil {
+		return err
+	}
+	for i, r := range releases {
+		res, err := tx.Exec(`INSERT INTO releases (provider, series, item, item_title, endpoint, detected_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			snap.Provider, snap.Series, r.Item, r.ItemTitle, r.Endpoint, snap.CheckedAt.UTC())
+		if err != nil {
+			return err
+		}
+		releases[i].ID, _ = res.LastInsertId()
+	}
+	return tx.Commit()
+}
+
+// Releases lists new chapters and episodes of the series a user follows,
+// detected after they followed it and after since, newest first
+func (s *Store) Releases(userID int64, since time.Time, limit int) ([]Release, error) {
+	releases := []Release{}
+	if s == nil {
+		return releases, nil
+	}
+	rows, err := s.db.Query(`SELECT r.id, r.provider, r.series, COALESCE(sn.title, ''), r.item, r.item_title, r.endpoint, r.detected_at
+		FROM releases r
+		JOIN follows f ON f.provider = r.provider AND f.series = r.series AND f.user_id = ?
+		LEFT JOIN snapshots sn ON sn.provider = r.provider AND sn.series = r.series
+		WHERE r.detected_at >= f.created_at AND r.detected_at > ?
+		ORDER BY r.detected_at DESC, r.id DESC LIMIT ?`, userID, since.UTC(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r Release
+		err := rows.Scan(&r.ID, &r.Provider, &r.Series, &r.SeriesTitle, &r.Item, &r.ItemTitle, &r.Endpoint, &r.DetectedAt)
+		if err != nil {
+			return nil, err
+		}
+		releases = append(releases, r)
+	}
+	return releases, rows.Err()
+}
+
+func splitItems(items string) []string {
+	if items == "" {
+		return nil
+	}
+	return strings.Split(items, "\n")
+}
@@ -0,0 +1,317 @@
+package users
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Event kinds recorded when a chapter or episode is finished
+const (
+	EventRead    = "read"
+	EventWatched = "watched"
+)
+
+// User is an account identified by its API tokens
+type User struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Progress is the resume position in one chapter or episode
+type Progress struct {
+	Provider  string    `json:"provider"`
+	Series    string    `json:"series"` // manga or anime slug
+	Item      string    `json:"item"`   // chapter or episode slug
+	ItemTitle string    `json:"item_title"`
+	Page      int       `json:"page"`     // page index for chapters
+	Position  float64   `json:"position"` // seconds into an episode
+	Duration  float64   `json:"duration"` // episode length in seconds, 0 when unknown
+	Completed bool      `json:"completed"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Event is one finished chapter or episode in a user's history
+type Event struct {
+	Kind      string    `json:"kind"` // EventRead or EventWatched
+	Provider  string    `json:"provider"`
+	Series    string    `json:"series"`
+	Item      string    `json:"item"`
+	ItemTitle string    `json:"item_title"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Store persists users, their tokens and their progress in SQLite
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore opens (or creates) the users database
+func NewStore() *Store {
+	dbPath := os.Getenv("USERS_DB_PATH")
+	if dbPath == "" {
+		dbPath = "./users.db"
+	}
+
+	db, err := sql.Open("sqlite3", dbPath)
+	if err != nil {
+		log.Printf("[Users] Failed to open SQLite: %v", err)
+		return nil
+	}
+
+	// Tokens are stored as SHA-256 hashes; the plain token is shown once
+	schema := `
+	CREATE TABLE IF NOT EXISTS users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS tokens (
+		hash TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		created_at DATETIME NOT NULL,
+		last_used_at DATETIME
+	);
+	CREATE TABLE IF NOT EXISTS progress (
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		provider TEXT NOT NULL,
+		series TEXT NOT NULL,
+		item TEXT NOT NULL,
+		item_title TEXT NOT NULL DEFAULT '',
+		page INTEGER NOT NULL DEFAULT 0,
+		position REAL NOT NULL DEFAULT 0,
+		duration REAL NOT NULL DEFAULT 0,
+		completed INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (user_id, provider, series, item)
+	);
+	CREATE TABLE IF NOT EXISTS events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		kind TEXT NOT NULL,
+		provider TEXT NOT NULL,
+		series TEXT NOT NULL,
+		item TEXT NOT NULL,
+		item_title TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_tokens_user ON tokens(user_id);
+	CREATE INDEX IF NOT EXISTS idx_progress_updated ON progress(user_id, updated_at);
+	CREATE INDEX IF NOT EXISTS idx_events_user ON events(user_id, created_at);
+	`
+
+	if _, err := db.Exec(schema + followSchema + webhookSchema); err != nil {
+		log.Printf("[Users] Failed to create schema: %v", err)
+		return nil
+	}
+
+	// SQLite only allows one writer; serialize access from request goroutines
+	db.SetMaxOpenConns(1)
+
+	return &Store{db: db}
+}
+
+// CreateUser adds a user and returns it with its first token
+func (s *Store) CreateUser(name string) (*User, string, error) {
+	if s == nil {
+		return nil, "", fmt.Errorf("user store is not available")
+	}
+	user := &User{Name: name, CreatedAt: time.Now().UTC()}
+	res, err := s.db.Exec(`INSERT INTO users (name, created_at) VALUES (?, ?)`, user.Name, user.CreatedAt)
+	if err != nil {
+		return nil, "", err
+	}
+	user.ID, _ = res.LastInsertId()
+
+	token, err := s.CreateToken(user.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, token, nil
+}
+
+// CreateToken issues another token for a user, e.g. for a second device
+func (s *Store) CreateToken(userID int64) (string, error) {
+	if s == nil {
+		return "", fmt.Errorf("user store is not available")
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(raw)
+	_, err := s.db.Exec(`INSERT INTO tokens (hash, user_id, created_at) VALUES (?, ?, ?)`,
+		hashToken(token), userID, time.Now().UTC())
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// RevokeToken deletes one token
+func (s *Store) RevokeToken(token string) error {
+	if s == nil {
+		return nil
+	}
+	_, err := s.db.Exec(`DELETE FROM tokens WHERE hash = ?`, hashToken(token))
+	return err
+}
+
+// Authenticate returns the user owning token, or nil when it is unknown
+func (s *Store) Authenticate(token string) (*User, error) {
+	if s == nil || token == "" {
+		return nil, nil
+	}
+	hash := hashToken(token)
+	var u User
+	err := s.db.QueryRow(`SELECT u.id, u.name, u.created_at FROM tokens t JOIN users u ON u.id = t.user_id WHERE t.hash = ?`,
+		hash).Scan(&u.ID, &u.Name, &u.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.db.Exec(`UPDATE tokens SET last_used_at = ? WHERE hash = ?`, time.Now().UTC(), hash)
+	return &u, nil
+}
+
+// SaveProgress stores the resume position of a chapter or episode. When it
+// is completed for the first time a read/watched event is added to the
+// history.
+func (s *Store) SaveProgress(userID int64, p Progress, eventKind string) error {
+	if s == nil {
+		return nil
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var wasCompleted bool
+	err = tx.QueryRow(`SELECT completed FROM progress WHERE user_id = ? AND provider = ? AND series = ? AND item = ?`,
+		userID, p.Provider, p.Series, p.Item).Scan(&wasCompleted)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	p.UpdatedAt = time.Now().UTC()
+	_, err = tx.Exec(`INSERT INTO progress (user_id, provider, series, item, item_title, page, position, duration, completed, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, provider, series, item) DO UPDATE SET
+			item_title = COALESCE(NULLIF(excluded.item_title, ''), item_title),
+			page = excluded.page,
+			position = excluded.position,
+			duration = COALESCE(NULLIF(excluded.duration, 0), duration),
+			completed = excluded.completed OR completed,
+			updated_at = excluded.updated_at`,
+		userID, p.Provider, p.Series, p.Item, p.ItemTitle, p.Page, p.Position, p.Duration, p.Completed, p.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if p.Completed && !wasCompleted {
+		_, err = tx.Exec(`INSERT INTO events (user_id, kind, provider, series, item, item_title, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			userID, eventKind, p.Provider, p.Series, p.Item, p.ItemTitle, p.UpdatedAt)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Progress lists a user's positions, most recent first. provider and series
+// narrow the list when set.
+func (s *Store) Progress(userID int64, provider, series string) ([]Progress, error) {
+	list := []Progress{}
+	if s == nil {
+		return list, nil
+	}
+	rows, err := s.db.Query(`SELECT provider, series, item, item_title, page, position, duration, completed, updated_at
+		FROM progress WHERE user_id = ? AND (? = '' OR provider = ?) AND (? = '' OR series = ?)
+		ORDER BY updated_at DESC`, userID, provider, provider, series, series)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p Progress
+		err := rows.Scan(&p.Provider, &p.Series, &p.Item, &p.ItemTitle, &p.Page, &p.Position, &p.Duration, &p.Completed, &p.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+// LatestPerSeries returns the most recently updated position of each series
+// the user has started on provider (all providers when empty)
+func (s *Store) LatestPerSeries(userID int64, provider string, limit int) ([]Progress, error) {
+	list := []Progress{}
+	if s == nil {
+		return list, nil
+	}
+	rows, err := s.db.Query(`SELECT provider, series, item, item_title, page, position, duration, completed, updated_at
+		FROM progress p WHERE user_id = ? AND (? = '' OR provider = ?)
+		AND updated_at = (SELECT MAX(updated_at) FROM progress q
+			WHERE q.user_id = p.user_id AND q.provider = p.provider AND q.series = p.series)
+		ORDER BY updated_at DESC LIMIT ?`, userID, provider, provider, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p Progress
+		err := rows.Scan(&p.Provider, &p.Series, &p.Item, &p.ItemTitle, &p.Page, &p.Position, &p.Duration, &p.Completed, &p.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, p)
+	}
+	return list, rows.Err()
+}
+
+// History lists a user's finished chapters and episodes, newest first
+func (s *Store) History(userID int64, limit, offset int) ([]Event, error) {
+	events := []Event{}
+	if s == nil {
+		return events, nil
+	}
+	rows, err := s.db.Query(`SELECT kind, provider, series, item, item_title, created_at FROM events
+		WHERE user_id = ? ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var e Event
+		if err := rows.Scan(&e.Kind, &e.Provider, &e.Series, &e.Item, &e.ItemTitle, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
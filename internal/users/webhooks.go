@@ -0,0 +1,178 @@
+package users
+
+import (
+	"fmt"
+	"time"
+)
+
+// Webhook sink kinds
+const (
+	SinkWebhook  = "webhook"  // the release event as JSON
+	SinkDiscord  = "discord"  // a Discord webhook message
+	SinkTelegram = "telegram" // a Telegram Bot API sendMessage call
+)
+
+// Webhook is a URL notified of new releases of the series its owner
+// follows. Empty Provider/Series filters match every followed series.
+type Webhook struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"-"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"-"`
+	Sink      string    `json:"sink"`
+	ChatID    string    `json:"chat_id,omitempty"` // telegram only
+	Provider  string    `json:"provider"`
+	Series    string    `json:"series"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DeadLetter is a delivery that failed every attempt
+type DeadLetter struct {
+	ID        int64     `json:"id"`
+	WebhookID int64     `json:"webhook_id"`
+	Event     string    `json:"event"`
+	Payload   string    `json:"payload"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+const webhookSchema = `
+CREATE TABLE IF NOT EXISTS webhooks (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL REFERENCES users(id),
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL DEFAULT '',
+	sink TEXT NOT NULL,
+	chat_id TEXT NOT NULL DEFAULT '',
+	provider TEXT NOT NULL DEFAULT '',
+	series TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL
+);
+CREATE TABLE IF NOT EXISTS dead_letters (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	webhook_id INTEGER NOT NULL,
+	event TEXT NOT NULL,
+	payload TEXT NOT NULL,
+	attempts INTEGER NOT NULL,
+	last_error TEXT NOT NULL DEFAULT '',
+	created_at DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_webhooks_user ON webhooks(user_id);
+CREATE INDEX IF NOT EXISTS idx_dead_letters_webhook ON dead_letters(webhook_id, created_at);
+`
+
+const webhookColumns = `id, user_id, url, secret, sink, chat_id, provider, series, created_at`
+
+// CreateWebhook stores a subscription and returns it with its ID
+func (s *Store) CreateWebhook(w Webhook) (*Webhook, error) {
+	if s == nil {
+		return nil, fmt.Errorf("user store is not available")
+	}
+	w.CreatedAt = time.Now().UTC()
+	res, err := s.db.Exec(`INSERT INTO webhooks (user_id, url, secret, sink, chat_id, provider, series, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`, w.UserID, w.URL, w.Secret, w.Sink, w.ChatID, w.Provider, w.Series, w.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	w.ID, _ = res.LastInsertId()
+	return &w, nil
+}
+
+// Webhook returns one of a user's webhooks, or nil when it does not exist
+func (s *Store) Webhook(userID, id int64) (*Webhook, error) {
+	if s == nil {
+		return nil, nil
+	}
+	list, err := s.queryWebhooks(`SELECT `+webhookColumns+` FROM webhooks WHERE user_id = ? AND id = ?`, userID, id)
+	if err != nil || len(list) == 0 {
+		return nil, err
+	}
+	return &list[0], nil
+}
+
+// Webhooks lists a user's webhooks, oldest first
+func (s *Store) Webhooks(userID int64) ([]Webhook, error) {
+	if s == nil {
+		return []Webhook{}, nil
+	}
+	return s.queryWebhooks(`SELECT `+webhookColumns+` FROM webhooks WHERE user_id = ? ORDER BY id`, userID)
+}
+
+// DeleteWebhook removes a user's webhook and its dead letters
+func (s *Store) DeleteWebhook(userID, id int64) error {
+	if s == nil {
+		return nil
+	}
+	res, err := s.db.Exec(`DELETE FROM webhooks WHERE user_id = ? AND id = ?`, userID, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		_, err = s.db.Exec(`DELETE FROM dead_letters WHERE webhook_id = ?`, id)
+	}
+	return err
+}
+
+// WebhooksFor lists the webhooks to notify of a release: those of users
+// following the series since before it was detected, whose filters match
+func (s *Store) WebhooksFor(r Release) ([]Webhook, error) {
+	if s == nil {
+		return []Webhook{}, nil
+	}
+	return s.queryWebhooks(`SELECT w.id, w.user_id, w.url, w.secret, w.sink, w.chat_id, w.provider, w.series, w.created_at
+		FROM webhooks w JOIN follows f ON f.user_id = w.user_id AND f.provider = ? AND f.series = ?
+		WHERE f.created_at <= ? AND (w.provider = '' OR w.provider = ?) AND (w.series = '' OR w.series = ?)
+		ORDER BY w.id`, r.Provider, r.Series, r.DetectedAt.UTC(), r.Provider, r.Series)
+}
+
+// SaveDeadLetter records a delivery that could not be made
+func (s *Store) SaveDeadLetter(d DeadLetter) error {
+	if s == nil {
+		return nil
+	}
+	_, err := s.db.Exec(`INSERT INTO dead_letters (webhook_id, event, payload, attempts, last_error, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		d.WebhookID, d.Event, d.Payload, d.Attempts, d.LastError, time.Now().UTC())
+	return err
+}
+
+// DeadLetters lists failed deliveries to a user's webhooks, newest first
+func (s *Store) DeadLetters(userID int64, limit int) ([]DeadLetter, error) {
+	letters := []DeadLetter{}
+	if s == nil {
+		return letters, nil
+	}
+	rows, err := s.db.Query(`SELECT d.id, d.webhook_id, d.event, d.payload, d.attempts, d.last_error, d.created_at
+		FROM dead_letters d JOIN webhooks w ON w.id = d.webhook_id
+		WHERE w.user_id = ? ORDER BY d.created_at DESC, d.id DESC LIMIT ?`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var d DeadLetter
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.Event, &d.Payload, &d.Attempts, &d.LastError, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		letters = append(letters, d)
+	}
+	return letters, rows.Err()
+}
+
+func (s *Store) queryWebhooks(query string, args ...any) ([]Webhook, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	list := []Webhook{}
+	for rows.Next() {
+		var w Webhook
+		err := rows.Scan(&w.ID, &w.UserID, &w.URL, &w.Secret, &w.Sink, &w.ChatID, &w.Provider, &w.Series, &w.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, w)
+	}
+	return list, rows.Err()
+}
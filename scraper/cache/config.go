@@ -18,20 +18,43 @@ const (
 
 	// StreamTTL for stream URLs (can expire quickly)
 	StreamTTL = 5 * time.Minute
+
+	// GenreTTL for genre lists (change only when a site adds a genre)
+	GenreTTL = 6 * time.Hour
+
+	// CatalogueTTL for full-site index crawls (expensive, titles change slowly)
+	CatalogueTTL = 24 * time.Hour
+
+	// FailureTTL for remembering that a source page failed, so it is not
+	// retried on every request while a fallback is served
+	FailureTTL = 2 * time.Minute
 )
 
 // CacheKey formats for consistent key generation
 const (
 	// Winbu cache key formats
-	WinbuHomeKey    = "winbu:home"
-	WinbuSearchKey  = "winbu:search:%s"  // winbu:search:naruto
-	WinbuDetailKey  = "winbu:detail:%s"  // winbu:detail:/anime/one-piece
-	WinbuEpisodeKey = "winbu:episode:%s" // winbu:episode:/anime/one-piece/episode-1
+	WinbuHomeKey      = "winbu:home"
+	WinbuSearchKey    = "winbu:search:%s"   // winbu:search:naruto
+	WinbuDetailKey    = "winbu:detail:%s"   // winbu:detail:/anime/one-piece
+	WinbuEpisodeKey   = "winbu:episode:%s"  // winbu:episode:/anime/one-piece/episode-1
+	WinbuStreamKey    = "winbu:stream:%s"   // winbu:stream:<post>:<nume>:<type>
+	WinbuDownloadKey  = "winbu:download:%s" // winbu:download:<shortener url>
+	WinbuSubtitleKey  = "winbu:subtitle:%s" // winbu:subtitle:<track url> -> referer
+	WinbuScheduleKey  = "winbu:schedule"
+	WinbuIndexKey     = "winbu:index:%s" // winbu:index:<archive url>
+	WinbuCatalogueKey = "winbu:catalogue"
+	WinbuDramaKey     = "winbu:drama"
+	WinbuGenresKey    = "winbu:genres"
 
 	// Komiku cache key formats
 	KomikuHomeKey    = "komiku:home"
 	KomikuPopularKey = "komiku:popular"
-	KomikuSearchKey  = "komiku:search:%s"  // komiku:search:dandadan
-	KomikuDetailKey  = "komiku:detail:%s"  // komiku:detail:/manga/dandadan
-	KomikuChapterKey = "komiku:chapter:%s" // komiku:chapter:/manga/dandadan/chapter-223
+	KomikuGenresKey  = "komiku:genres"
+	KomikuSearchKey  = "komiku:search:%s"     // komiku:search:dandadan
+	KomikuDetailKey  = "komiku:detail:%s"     // komiku:detail:/manga/dandadan
+	KomikuChapterKey = "komiku:chapter:%s"    // komiku:chapter:/manga/dandadan/chapter-223
+	KomikuRankingKey = "komiku:ranking:%s:%s" // komiku:ranking:<period>:<type>
+	KomikuBrowseKey  = "komiku:browse:%s"     // komiku:browse:<listing url>
+
+	KomikuRankingDownKey = "komiku:ranking-down:%s:%s" // set while the peringkat page fails
 )
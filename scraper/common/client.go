@@ -13,7 +13,9 @@ import (
 // BaseClient provides common HTTP client functionality for all scrapers
 type BaseClient struct {
 	Client      *http.Client
-	ServiceName string // e.g., "Winbu" or "Komiku"
+	ServiceName string   // e.g., "Winbu" or "Komiku"
+	Limiter     *Limiter // per-host throttle, nil to disable
+	Priority    Priority // used when the request context sets none
 }
 
 // NewBaseClient creates a new BaseClient with default configuration
@@ -49,9 +51,18 @@ func NewBaseClient(serviceName string) *BaseClient {
 			Transport: transport,
 		},
 		ServiceName: serviceName,
+		Limiter:     DefaultLimiter,
 	}
 }
 
+// WithPriority returns a client sharing c's connections and limiter whose
+// requests default to priority p
+func (c *BaseClient) WithPriority(p Priority) *BaseClient {
+	cp := *c
+	cp.Priority = p
+	return &cp
+}
+
 // Do executes an HTTP request with common headers and logging
 func (c *BaseClient) Do(req *http.Request) (*http.Response, error) {
 	// Set complete browser-like headers
@@ -68,6 +79,21 @@ func (c *BaseClient) Do(req *http.Request) (*http.Response, error) {
 		req.Header.Set("Referer", req.URL.Scheme+"://"+req.URL.Host+"/")
 	}
 
+	// Wait for the host's rate and in-flight budget; the slot is held until
+	// the response headers arrive
+	if c.Limiter != nil {
+		priority := PriorityFrom(req.Context(), c.Priority)
+		start := time.Now()
+		release, err := c.Limiter.Acquire(req.Context(), req.URL.Host, priority)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+		if waited := time.Since(start); waited > time.Second {
+			log.Printf("[%s] Throttled %s request to %s for %s", c.ServiceName, priority, req.URL.Host, waited.Round(time.Millisecond))
+		}
+	}
+
 	// Log the request
 	log.Printf("[%s] Fetching: %s", c.ServiceName, req.URL.String())
 
@@ -0,0 +1,225 @@
+package common
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Priority orders requests waiting for the same host. Lower values go first.
+type Priority int
+
+const (
+	// PriorityInteractive is for requests a user is waiting on (the default)
+	PriorityInteractive Priority = iota
+	// PriorityBackground is for crawls, polls, cache warming and downloads
+	PriorityBackground
+
+	numPriorities
+)
+
+func (p Priority) String() string {
+	if p == PriorityBackground {
+		return "background"
+	}
+	return "interactive"
+}
+
+type priorityKey struct{}
+
+// WithPriority marks requests made with ctx as having priority p
+func WithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// PriorityFrom returns the priority set on ctx, or def when there is none
+func PriorityFrom(ctx context.Context, def Priority) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok && p >= 0 && p < numPriorities {
+		return p
+	}
+	return def
+}
+
+// Limiter throttles outbound requests per host with a token bucket and a
+// cap on requests in flight. When a host is saturated, waiting requests are
+// let through by priority first and arrival second.
+type Limiter struct {
+	Rate        float64 // requests per second per host
+	Burst       int     // requests a host may take at once after idling
+	MaxInFlight int     // concurrent requests per host
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimiter
+}
+
+// DefaultLimiter is shared by every BaseClient, so all scrapers and all
+// clients of one scraper draw on the same per-host budget
+var DefaultLimiter = LimiterFromEnv()
+
+func NewLimiter(rate float64, burst, maxInFlight int) *Limiter {
+	return &Limiter{Rate: rate, Burst: burst, MaxInFlight: maxInFlight, hosts: make(map[string]*hostLimiter)}
+}
+
+// LimiterFromEnv builds a limiter from UPSTREAM_RATE (default 4 per second),
+// UPSTREAM_BURST (default 8) and UPSTREAM_MAX_IN_FLIGHT (default 6)
+func LimiterFromEnv() *Limiter {
+	rate := 4.0
+	if v, err := strconv.ParseFloat(os.Getenv("UPSTREAM_RATE"), 64); err == nil && v > 0 {
+		rate = v
+	}
+	burst := 8
+	if v, err := strconv.Atoi(os.Getenv("UPSTREAM_BURST")); err == nil && v > 0 {
+		burst = v
+	}
+	maxInFlight := 6
+	if v, err := strconv.Atoi(os.Getenv("UPSTREAM_MAX_IN_FLIGHT")); err == nil && v > 0 {
+		maxInFlight = v
+	}
+	return NewLimiter(rate, burst, maxInFlight)
+}
+
+// Acquire waits until a request to host may start and returns the function
+// that ends it. It fails only when ctx is done first.
+func (l *Limiter) Acquire(ctx context.Context, host string, p Priority) (func(), error) {
+	if p < 0 || p >= numPriorities {
+		p = PriorityInteractive
+	}
+	h := l.host(host)
+
+	w := &waiter{ready: make(chan struct{})}
+	h.mu.Lock()
+	h.queues[p] = append(h.queues[p], w)
+	h.dispatch()
+	h.mu.Unlock()
+
+	for {
+		h.mu.Lock()
+		wait := h.untilToken()
+		h.mu.Unlock()
+		timer := time.NewTimer(wait)
+
+		select {
+		case <-w.ready:
+			timer.Stop()
+			return h.releaser(), nil
+		case <-ctx.Done():
+			timer.Stop()
+			h.mu.Lock()
+			select {
+			case <-w.ready:
+				// Granted while giving up: hand the slot back
+				h.inFlight--
+			default:
+				h.remove(p, w)
+			}
+			h.dispatch()
+			h.mu.Unlock()
+			return nil, ctx.Err()
+		case <-timer.C:
+			// A token may have been refilled; let the head of the queue take it
+			h.mu.Lock()
+			h.dispatch()
+			h.mu.Unlock()
+		}
+	}
+}
+
+func (l *Limiter) host(name string) *hostLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h, ok := l.hosts[name]
+	if !ok {
+		h = &hostLimiter{
+			rate:        l.Rate,
+			burst:       float64(l.Burst),
+			maxInFlight: l.MaxInFlight,
+			tokens:      float64(l.Burst),
+			last:        time.Now(),
+		}
+		l.hosts[name] = h
+	}
+	return h
+}
+
+type waiter struct {
+	ready chan struct{}
+}
+
+type hostLimiter struct {
+	mu          sync.Mutex
+	rate        float64
+	burst       float64
+	maxInFlight int
+	tokens      float64
+	last        time.Time
+	inFlight    int
+	queues      [numPriorities][]*waiter
+}
+
+// dispatch lets queued requests start while tokens and slots allow, highest
+// priority first. Callers hold h.mu.
+func (h *hostLimiter) dispatch() {
+	now := time.Now()
+	h.tokens += now.Sub(h.last).Seconds() * h.rate
+	if h.tokens > h.burst {
+		h.tokens = h.burst
+	}
+	h.last = now
+
+	for h.tokens >= 1 && h.inFlight < h.maxInFlight {
+		w := h.next()
+		if w == nil {
+			return
+		}
+		h.tokens--
+		h.inFlight++
+		close(w.ready)
+	}
+}
+
+func (h *hostLimiter) next() *waiter {
+	for p := range h.queues {
+		if len(h.queues[p]) > 0 {
+			w := h.queues[p][0]
+			h.queues[p] = h.queues[p][1:]
+			return w
+		}
+	}
+	return nil
+}
+
+func (h *hostLimiter) remove(p Priority, w *waiter) {
+	q := h.queues[p]
+	for i := range q {
+		if q[i] == w {
+			h.queues[p] = append(q[:i:i], q[i+1:]...)
+			return
+		}
+	}
+}
+
+// untilToken is how long until the bucket holds a whole token again. With
+// tokens to spare waiters are blocked on slots, which release wakes them
+// for, so they only recheck occasionally. Callers hold h.mu.
+func (h *hostLimiter) untilToken() time.Duration {
+	if h.tokens >= 1 {
+		return time.Second
+	}
+	return time.Duration((1 - h.tokens) / h.rate * float64(time.Second))
+}
+
+// releaser returns the function ending one request; calling it again is a
+// no-op
+func (h *hostLimiter) releaser() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			h.mu.Lock()
+			h.inFlight--
+			h.dispatch()
+			h.mu.Unlock()
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package common
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitQueued blocks until n requests wait on host at priority p
+func waitQueued(t *testing.T, l *Limiter, host string, p Priority, n int) {
+	t.Helper()
+	h := l.host(host)
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		h.mu.Lock()
+		queued := len(h.queues[p])
+		h.mu.Unlock()
+		if queued == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("%d %s requests never queued", n, p)
+}
+
+func TestLimiterPriorityOrder(t *testing.T) {
+	l := NewLimiter(1000, 1000, 1)
+	const host = "komiku.org"
+
+	hold, err := l.Acquire(context.Background(), host, PriorityBackground)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu    sync.Mutex
+		order []string
+		wg    sync.WaitGroup
+	)
+	start := func(name string, p Priority, queued int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := l.Acquire(context.Background(), host, p)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			release()
+		}()
+		waitQueued(t, l, host, p, queued)
+	}
+
+	// Background requests arrive first, interactive ones must still go first
+	start("background-1", PriorityBackground, 1)
+	start("background-2", PriorityBackground, 2)
+	start("interactive-1", PriorityInteractive, 1)
+	start("interactive-2", PriorityInteractive, 2)
+
+	hold()
+	wg.Wait()
+
+	want := []string{"interactive-1", "interactive-2", "background-1", "background-2"}
+	if len(order) != len(want) {
+		t.Fatalf("order = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("order = %v, want %v", order, want)
+		}
+	}
+}
+
+func TestLimiterHostsAreIndependent(t *testing.T) {
+	l := NewLimiter(1000, 1000, 1)
+	hold, err := l.Acquire(context.Background(), "komiku.org", PriorityInteractive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hold()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	release, err := l.Acquire(ctx, "winbu.net", PriorityBackground)
+	if err != nil {
+		t.Fatalf("other host blocked: %v", err)
+	}
+	release()
+}
+
+func TestLimiterCancelledWaiterLeavesQueue(t *testing.T) {
+	l := NewLimiter(1000, 1000, 1)
+	const host = "winbu.net"
+	hold, err := l.Acquire(context.Background(), host, PriorityInteractive)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx, host, PriorityInteractive); err != context.DeadlineExceeded {
+		t.Fatalf("err = %v, want deadline exceeded", err)
+	}
+	waitQueued(t, l, host, PriorityInteractive, 0)
+
+	// The slot freed by hold goes to the next request, not the cancelled one
+	hold()
+	ctx2, cancel2 := context.WithTimeout(context.Background(), time.Second)
+	defer cancel2()
+	release, err := l.Acquire(ctx2, host, PriorityBackground)
+	if err != nil {
+		t.Fatalf("slot was not freed: %v", err)
+	}
+	release()
+}
+
+func TestLimiterRate(t *testing.T) {
+	l := NewLimiter(50, 1, 10)
+	const host = "komiku.org"
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		release, err := l.Acquire(context.Background(), host, PriorityInteractive)
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+	// One token up front, then one every 20ms
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("4 requests at 50/s with burst 1 took %s, want about 60ms", elapsed)
+	}
+}
+
+func TestPriorityFrom(t *testing.T) {
+	ctx := context.Background()
+	if got := PriorityFrom(ctx, PriorityBackground); got != PriorityBackground {
+		t.Errorf("default = %s, want background", got)
+	}
+	if got := PriorityFrom(WithPriority(ctx, PriorityInteractive), PriorityBackground); got != PriorityInteractive {
+		t.Errorf("explicit = %s, want interactive", got)
+	}
+	if got := PriorityFrom(WithPriority(ctx, Priority(7)), PriorityInteractive); got != PriorityInteractive {
+		t.Errorf("out of range = %s, want the default", got)
+	}
+}
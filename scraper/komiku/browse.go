@@ -0,0 +1,132 @@
+package komiku
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Browse sort orders, mapped to komiku's "orderby" values
+const (
+	SortUpdated = "update"
+	SortPopular = "popular"
+	SortTitle   = "title"
+	SortNewest  = "new"
+)
+
+var sortOrders = map[string]string{
+	SortUpdated: "modified",
+	SortPopular: "meta_value_num",
+	SortTitle:   "title",
+	SortNewest:  "date",
+}
+
+// Browse statuses, mapped to komiku's "status" values
+var browseStatuses = map[string]string{
+	"ongoing":   "ongoing",
+	"completed": "end",
+	"end":       "end",
+}
+
+// browseBaseURL serves the "daftar komik" listing and its filter form
+const browseBaseURL = "https://api.komiku.org/manga/"
+
+// BrowseFilter selects a page of komiku's filterable comic listing
+type BrowseFilter struct {
+	Type   string   // "manga", "manhwa", "manhua" or "" for all
+	Genres []string // genre slugs; komiku accepts at most two
+	Status string   // "ongoing", "completed" or ""
+	Sort   string   // Sort* constant, SortUpdated when empty
+	Page   int      // 1-based
+}
+
+// BrowseResult is one page of browse results
+type BrowseResult struct {
+	Items      []Manga
+	Page       int
+	HasNext    bool
+	TotalPages int // 0 when the page does not show numbered pagination
+}
+
+// ValidateBrowseFilter normalises f and rejects values komiku does not know
+func ValidateBrowseFilter(f *BrowseFilter) error {
+	f.Type = strings.ToLower(f.Type)
+	if !ValidComicType(f.Type) {
+		return fmt.Errorf("type must be manga, manhwa or manhua")
+	}
+	f.Status = strings.ToLower(f.Status)
+	if _, ok := browseStatuses[f.Status]; f.Status != "" && !ok {
+		return fmt.Errorf("status must be ongoing or completed")
+	}
+	f.Sort = strings.ToLower(f.Sort)
+	if f.Sort == "" {
+		f.Sort = SortUpdated
+	}
+	if _, ok := sortOrders[f.Sort]; !ok {
+		return fmt.Errorf("sort must be update, popular, title or new")
+	}
+	if len(f.Genres) > 2 {
+		return fmt.Errorf("at most two genres can be combined")
+	}
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	return nil
+}
+
+// BrowseURL builds the listing URL for f, which must be validated
+func BrowseURL(f BrowseFilter) string {
+	q := url.Values{}
+	q.Set("orderby", sortOrders[f.Sort])
+	q.Set("tipe", f.Type)
+	q.Set("genre", "")
+	q.Set("genre2", "")
+	for i, g := range f.Genres {
+		key := "genre"
+		if i == 1 {
+			key = "genre2"
+		}
+		q.Set(key, strings.ToLower(strings.TrimSpace(g)))
+	}
+	q.Set("status", browseStatuses[f.Status])
+
+	base := browseBaseURL
+	if f.Page > 1 {
+		base += "page/" + strconv.Itoa(f.Page) + "/"
+	}
+	return base + "?" + q.Encode()
+}
+
+// ParseBrowse parses one listing page and its pagination
+func ParseBrowse(doc *goquery.Document, page int) (*BrowseResult, error) {
+	items, err := ParseMangaList(doc)
+	if err != nil {
+		return nil, err
+	}
+	if items == nil {
+		items = []Manga{}
+	}
+
+	result := &BrowseResult{Items: items, Page: page}
+
+	// Numbered pagination on full pages, an hx-get "load more" trigger on
+	// the infinite-scroll fragments served by api.komiku.org
+	next := fmt.Sprintf("/page/%d/", page+1)
+	result.HasNext = doc.Find("a.next, link[rel='next']").Length() > 0
+	doc.Find("[hx-get], .pagination a, a.page-numbers").Each(func(i int, s *goquery.Selection) {
+		href := s.AttrOr("hx-get", s.AttrOr("href", ""))
+		if strings.Contains(href, next) {
+			result.HasNext = true
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(s.Text())); err == nil && n > result.TotalPages {
+			result.TotalPages = n
+		}
+	})
+	if result.TotalPages > 0 && result.TotalPages < page {
+		result.TotalPages = page
+	}
+	return result, nil
+}
@@ -0,0 +1,71 @@
+package komiku
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var (
+	comicTypePattern = regexp.MustCompile(`(?i)\b(manga|manhwa|manhua)\b`)
+	// "15jt pembaca", "1.2rb pembaca"
+	readersPattern = regexp.MustCompile(`(?i)[\d.,]+\s*(?:rb|jt|k|m)?\s*pembaca`)
+	// "2 jam lalu", "3 hari yang lalu", "kemarin"
+	updatedPattern = regexp.MustCompile(`(?i)\b(?:\d+\s+(?:detik|menit|jam|hari|minggu|bulan|tahun)(?:\s+yang)?\s+lalu|kemarin|baru\s+saja)\b`)
+	chapterPattern = regexp.MustCompile(`(?i)\bchapter\s*[\d.]+`)
+)
+
+// cardInfoSelectors hold the "Manga Fantasi 2 jam lalu" style info line of
+// the different list card layouts (.bge search results, ls2/ls4/ls8 home)
+const cardInfoSelectors = ".tpe1_inf, .judul2, .ls2t, .ls4s, .ls8t, .ls2s"
+
+// fillCardInfo sets Type, Genre, Score, Description, LatestChapter and
+// UpdatedAt from a list card, leaving fields the layout lacks empty
+func fillCardInfo(s *goquery.Selection, m *Manga) {
+	var info []string
+	s.Find(cardInfoSelectors).Each(func(i int, el *goquery.Selection) {
+		info = append(info, strings.Join(strings.Fields(el.Text()), " "))
+	})
+	text := strings.Join(info, " | ")
+
+	// Type is usually bold inside the thumbnail badge
+	if t := comicTypePattern.FindString(s.Find(".tpe1_inf b").Text()); t != "" {
+		m.Type = ParseComicType(t)
+	} else if t := comicTypePattern.FindString(text); t != "" {
+		m.Type = ParseComicType(t)
+	}
+
+	m.UpdatedAt = updatedPattern.FindString(text)
+
+	// Genre is what remains of the badge / info line after removing the
+	// type, reader count and update time
+	for _, part := range info {
+		part = comicTypePattern.ReplaceAllString(part, "")
+		part = readersPattern.ReplaceAllString(part, "")
+		part = updatedPattern.ReplaceAllString(part, "")
+		part = strings.Trim(strings.Join(strings.Fields(part), " "), " |•-")
+		if part != "" && !strings.Contains(part, "|") && !strings.EqualFold(part, "berwarna") {
+			m.Genre = part
+			break
+		}
+	}
+
+	m.Score = strings.TrimSpace(s.Find(".numscore, .rating, [itemprop='ratingValue']").First().Text())
+	m.Description = strings.Join(strings.Fields(s.Find(".kan p, .ls4j p, .ls2j p").First().Text()), " ")
+
+	// Latest chapter: the "Terbaru:" box on search cards, else the last
+	// chapter link of the card
+	s.Find(".new1").Each(func(i int, el *goquery.Selection) {
+		if strings.Contains(strings.ToLower(el.Text()), "terbaru") {
+			m.LatestChapter = chapterPattern.FindString(el.Text())
+		}
+	})
+	if m.LatestChapter == "" {
+		s.Find("a").Each(func(i int, el *goquery.Selection) {
+			if c := chapterPattern.FindString(el.Text()); c != "" {
+				m.LatestChapter = c
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package komiku
+
+import (
+	"regexp"
+	"strings"
+)
+
+// applyInfoRow copies one .inftable row into its typed MangaDetail field
+func applyInfoRow(detail *MangaDetail, label, value string) {
+	switch {
+	case strings.Contains(label, "judul indonesia"), strings.Contains(label, "judul alternatif"):
+		detail.AltTitle = value
+	case strings.Contains(label, "jenis"), label == "tipe", label == "type":
+		detail.Type = ParseComicType(value)
+	case strings.Contains(label, "konsep"):
+		detail.Concept = value
+	case strings.Contains(label, "pengarang"), strings.Contains(label, "author"):
+		detail.Authors = SplitAuthors(value)
+	case strings.Contains(label, "status"):
+		detail.Status = value
+	case strings.Contains(label, "cara baca"):
+		detail.ReadingDirection = ParseReadingDirection(value)
+	case strings.Contains(label, "umur"), strings.Contains(label, "usia"):
+		detail.AgeRating = ParseAgeRating(value)
+	}
+}
+
+// ParseComicType normalises "Jenis Komik" to Manga, Manhwa or Manhua
+func ParseComicType(value string) string {
+	lower := strings.ToLower(value)
+	for _, t := range []string{"Manhwa", "Manhua", "Manga"} {
+		if strings.Contains(lower, strings.ToLower(t)) {
+			return t
+		}
+	}
+	return value
+}
+
+// ParseReadingDirection maps "Kanan ke Kiri" style values to Read* constants
+func ParseReadingDirection(value string) string {
+	lower := strings.ToLower(value)
+	switch {
+	case strings.Contains(lower, "kanan ke kiri"), strings.Contains(lower, "right to left"):
+		return ReadRightToLeft
+	case strings.Contains(lower, "kiri ke kanan"), strings.Contains(lower, "left to right"):
+		return ReadLeftToRight
+	case strings.Contains(lower, "atas ke bawah"), strings.Contains(lower, "top to bottom"), strings.Contains(lower, "vertikal"), strings.Contains(lower, "vertical"):
+		return ReadTopToBottom
+	}
+	return ""
+}
+
+var agePattern = regexp.MustCompile(`\d+`)
+
+// ParseAgeRating turns "15 Tahun (minimal)" into "15+"; values without a
+// number are returned as is
+func ParseAgeRating(value string) string {
+	if m := agePattern.FindString(value); m != "" {
+		return m + "+"
+	}
+	return value
+}
+
+var authorSeparator = regexp.MustCompile(`\s*(?:,|;|&|/|\s+dan\s+|\s+and\s+)\s*`)
+
+// SplitAuthors splits "ONE, Murata Yusuke" or "Chugong & DUBU" into names
+func SplitAuthors(value string) []string {
+	var authors []string
+	for _, a := range authorSeparator.Split(value, -1) {
+		if a = strings.TrimSpace(a); a != "" {
+			authors = append(authors, a)
+		}
+	}
+	return authors
+}
@@ -48,11 +48,13 @@ func ParseMangaList(doc *goquery.Document) ([]Manga, error) {
 
 		if title != "" && endpoint != "" {
 			log.Printf("[Parser DEBUG] ✓ Adding manga: %s", title)
-			mangas = append(mangas, Manga{
+			m := Manga{
 				Title:    title,
 				Endpoint: endpoint,
 				Thumb:    thumb,
-			})
+			}
+			fillCardInfo(s, &m)
+			mangas = append(mangas, m)
 		} else {
 			log.Printf("[Parser DEBUG] ✗ Skipping item %d - missing title or endpoint", i)
 		}
@@ -75,16 +77,19 @@ func ParseMangaDetail(doc *goquery.Document) (*MangaDetail, error) {
 	detail.Description = detail.Synopsis // UI Compatibility
 
 	// Metadata Table
+	detail.Metadata = make(map[string]string)
 	doc.Find(".inftable tr").Each(func(i int, s *goquery.Selection) {
-		label := strings.ToLower(strings.TrimSpace(s.Find("td").First().Text()))
-		value := strings.TrimSpace(s.Find("td").Last().Text())
-
-		if strings.Contains(label, "pengarang") {
-			detail.Authors = []string{value}
+		cells := s.Find("td")
+		if cells.Length() < 2 {
+			return
 		}
-		if strings.Contains(label, "status") {
-			detail.Status = value
+		label := strings.Join(strings.Fields(cells.First().Text()), " ")
+		value := strings.Join(strings.Fields(cells.Last().Text()), " ")
+		if label == "" || value == "" {
+			return
 		}
+		detail.Metadata[label] = value
+		applyInfoRow(&detail, strings.ToLower(label), value)
 	})
 
 	// Genre
@@ -139,9 +144,9 @@ func ParseHomeData(doc *goquery.Document) (*HomeData, error) {
 		}
 
 		if title != "" && endpoint != "" {
-			data.Popular = append(data.Popular, Manga{
-				Title: title, Endpoint: endpoint, Thumb: thumb,
-			})
+			m := Manga{Title: title, Endpoint: endpoint, Thumb: thumb}
+			fillCardInfo(s, &m)
+			data.Popular = append(data.Popular, m)
 		}
 	})
 
@@ -161,14 +166,17 @@ func ParseHomeData(doc *goquery.Document) (*HomeData, error) {
 		}
 
 		if title != "" && endpoint != "" {
-			data.Latest = append(data.Latest, Manga{
-				Title: title, Endpoint: endpoint, Thumb: thumb,
-			})
+			m := Manga{Title: title, Endpoint: endpoint, Thumb: thumb}
+			fillCardInfo(s, &m)
+			data.Latest = append(data.Latest, m)
 		}
 	})
 
-	// Ensure Trending is initialized to avoid nil
-	data.Trending = []Manga{}
+	// Trending - the ranking box on the home page
+	data.Trending = parseRankedCards(doc.Find("#Trending_Komik article, #Peringkat article, #Peringkat .ls3"))
+	if data.Trending == nil {
+		data.Trending = []Manga{}
+	}
 
 	return &data, nil
 }
@@ -220,9 +228,9 @@ func ParseRecommendations(doc *goquery.Document) ([]Manga, error) {
 		}
 
 		if title != "" && endpoint != "" {
-			recommendations = append(recommendations, Manga{
-				Title: title, Endpoint: endpoint, Thumb: thumb,
-			})
+			m := Manga{Title: title, Endpoint: endpoint, Thumb: thumb}
+			fillCardInfo(s, &m)
+			recommendations = append(recommendations, m)
 		}
 	})
 
@@ -0,0 +1,109 @@
+package komiku
+
+import (
+	"fmt"
+	"komiku-scraper/scraper/common"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Ranking periods accepted by RankingURL
+const (
+	PeriodDaily   = "daily"
+	PeriodWeekly  = "weekly"
+	PeriodAllTime = "all"
+)
+
+// rankingPeriods maps our period names to komiku's "periode" values
+var rankingPeriods = map[string]string{
+	PeriodDaily:   "harian",
+	PeriodWeekly:  "mingguan",
+	PeriodAllTime: "semua",
+}
+
+// ComicTypes are the "tipe" filters komiku understands
+var ComicTypes = []string{"manga", "manhwa", "manhua"}
+
+// ValidRankingPeriod reports whether period is one of the Period* constants
+func ValidRankingPeriod(period string) bool {
+	_, ok := rankingPeriods[period]
+	return ok
+}
+
+// ValidComicType reports whether t is "" (all types) or one of ComicTypes
+func ValidComicType(t string) bool {
+	if t == "" {
+		return true
+	}
+	for _, ct := range ComicTypes {
+		if t == ct {
+			return true
+		}
+	}
+	return false
+}
+
+// RankingURL builds the peringkat page URL for a period and optional type
+func RankingURL(period, comicType string) string {
+	url := fmt.Sprintf("%s/peringkat/?periode=%s", common.KomikuBaseURL, rankingPeriods[period])
+	if comicType != "" {
+		url += "&tipe=" + comicType
+	}
+	return url
+}
+
+// rankingSelectors are tried in order; the first one with results wins.
+// They are scoped to the ranking container: generic card selectors would
+// also match ordinary listings and hide a layout change.
+var rankingSelectors = []string{
+	"#Peringkat article",
+	".peringkat article",
+	"#Peringkat .ls3, #Peringkat li",
+}
+
+// ParseRanking parses a peringkat page into a ranked list
+func ParseRanking(doc *goquery.Document) ([]Manga, error) {
+	for _, sel := range rankingSelectors {
+		if items := parseRankedCards(doc.Find(sel)); len(items) > 0 {
+			return items, nil
+		}
+	}
+	return []Manga{}, nil
+}
+
+// parseRankedCards reads cards of any list layout in page order. Rank comes
+// from the card's rank badge when present, else from its position.
+func parseRankedCards(cards *goquery.Selection) []Manga {
+	var items []Manga
+	cards.Each(func(i int, s *goquery.Selection) {
+		titleEl := s.Find("h3 a, h4 a, .kan a h3, h3, h4").First()
+		title := strings.TrimSpace(titleEl.Text())
+		endpoint := titleEl.AttrOr("href", "")
+		if endpoint == "" {
+			endpoint = titleEl.Closest("a").AttrOr("href", "")
+		}
+		if endpoint == "" {
+			endpoint = s.Find("a[href*='/manga/']").First().AttrOr("href", "")
+		}
+		if title == "" || endpoint == "" {
+			return
+		}
+
+		img := s.Find("img").First()
+		thumb := img.AttrOr("src", "")
+		if thumb == "" || strings.Contains(thumb, "lazy.jpg") {
+			thumb = img.AttrOr("data-src", "")
+		}
+		thumb = common.RemoveLazyPlaceholder(common.CleanImageURL(thumb))
+
+		m := Manga{Title: title, Endpoint: endpoint, Thumb: thumb, Rank: len(items) + 1}
+		if n, err := strconv.Atoi(strings.Trim(strings.TrimSpace(s.Find(".rank, .nomor, .num, .ls3n").First().Text()), "#.")); err == nil && n > 0 {
+			m.Rank = n
+		}
+		fillCardInfo(s, &m)
+		items = append(items, m)
+	})
+	return items
+}
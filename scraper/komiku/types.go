@@ -1,26 +1,42 @@
 package komiku
 
 type Manga struct {
-	Title       string
-	Endpoint    string
-	Thumb       string
-	Type        string
-	Score       string
-	Description string
+	Title         string
+	Endpoint      string
+	Thumb         string
+	Type          string // "Manga", "Manhwa" or "Manhua"
+	Score         string
+	Description   string
+	Genre         string // genre tag shown on the card, e.g. "Fantasi"
+	LatestChapter string // e.g. "Chapter 120"
+	UpdatedAt     string // relative time as shown, e.g. "2 jam lalu"
+	Rank          int    // position in trending/ranking lists, 0 elsewhere
 }
 
 type MangaDetail struct {
-	Title       string
-	Thumb       string
-	Synopsis    string
-	Description string // For UI compatibility
-	Status      string
-	Authors     []string
-	Genres      []string
-	Chapters    []ChapterLink
-	Metadata    map[string]string
+	Title            string
+	AltTitle         string // "Judul Indonesia"
+	Thumb            string
+	Synopsis         string
+	Description      string // For UI compatibility
+	Type             string // "Manga", "Manhwa" or "Manhua"
+	Concept          string // "Konsep Cerita", e.g. "Isekai, Reinkarnasi"
+	Status           string
+	ReadingDirection string // ReadRightToLeft, ReadLeftToRight or ReadTopToBottom
+	AgeRating        string // "15+" when a minimum age is given
+	Authors          []string
+	Genres           []string
+	Chapters         []ChapterLink
+	Metadata         map[string]string // every .inftable row, label -> value
 }
 
+// Reading directions from the "Cara Baca" row
+const (
+	ReadRightToLeft = "rtl" // manga
+	ReadLeftToRight = "ltr"
+	ReadTopToBottom = "ttb" // webtoon-style manhwa/manhua
+)
+
 type ChapterLink struct {
 	Title        string
 	Endpoint     string
@@ -29,6 +45,16 @@ type ChapterLink struct {
 	ViewCount    string
 }
 
+// Ranking is one peringkat list. Fallback is set when the peringkat page
+// could not be read and Items come from the home page trending box instead,
+// which has no notion of Period.
+type Ranking struct {
+	Period   string
+	Type     string
+	Fallback bool
+	Items    []Manga
+}
+
 type HomeData struct {
 	Trending []Manga
 	Popular  []Manga
@@ -0,0 +1,105 @@
+package winbu
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Episode kinds returned by ParseEpisodeNumber
+const (
+	KindEpisode = "episode"
+	KindOVA     = "ova" // also OAD/ONA
+	KindSpecial = "special"
+	KindMovie   = "movie"
+)
+
+var (
+	ovaPattern     = regexp.MustCompile(`(?i)\b(?:ova|oad|ona)\b(?:\s*[-:#]?\s*(\d+(?:\.\d+)?))?`)
+	specialPattern = regexp.MustCompile(`(?i)\b(?:special|spesial|sp)\b(?:\s*[-:#]?\s*(\d+(?:\.\d+)?))?`)
+	episodePattern = regexp.MustCompile(`(?i)\b(?:episode|eps|ep)\.?\s*[-:#]?\s*(\d+(?:\.\d+)?)\b`)
+	moviePattern   = regexp.MustCompile(`(?i)\b(?:movie|film)\b(?:\s*[-:#]?\s*(\d+)\b)?`)
+)
+
+// ParseEpisodeNumber reads the episode kind and number from a title or URL
+// slug: "Episode 12" → ("episode", "12"), "OVA 2" → ("ova", "2"),
+// "Special" → ("special", ""), "Movie" → ("movie", ""). Hyphenated slugs
+// such as "one-piece-episode-1100" work too. Returns "" kind when nothing
+// recognisable is found. An explicit "Episode N" wins over kind keywords, so
+// "Special A Episode 5" is episode 5 rather than a special.
+func ParseEpisodeNumber(text string) (kind, number string) {
+	text = strings.ReplaceAll(text, "-", " ")
+	if m := episodePattern.FindStringSubmatch(text); m != nil {
+		return KindEpisode, trimZeros(m[1])
+	}
+	if m := ovaPattern.FindStringSubmatch(text); m != nil {
+		return KindOVA, m[1]
+	}
+	if m := specialPattern.FindStringSubmatch(text); m != nil {
+		return KindSpecial, m[1]
+	}
+	if m := moviePattern.FindStringSubmatch(text); m != nil {
+		return KindMovie, m[1]
+	}
+	return "", ""
+}
+
+// trimZeros turns "012" into "12" but keeps "0" and "0.5"
+func trimZeros(n string) string {
+	for len(n) > 1 && n[0] == '0' && n[1] != '.' {
+		n = n[1:]
+	}
+	return n
+}
+
+// episodeSuffix matches the episode part of an episode slug,
+// e.g. "-episode-12-subtitle-indonesia"
+var episodeSuffix = regexp.MustCompile(`(?i)-(?:episode|eps|ep|ova|oad|ona|special|sp)-?\d*(?:-.*)?$`)
+
+// SlugFromURL returns the last path segment of a URL
+func SlugFromURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ""
+	}
+	slug := path.Base(strings.TrimSuffix(u.Path, "/"))
+	if slug == "." || slug == "/" {
+		return ""
+	}
+	return slug
+}
+
+// SeriesSlugFromURL returns the parent series slug of an anime, film or
+// episode URL: "/anime/one-piece/" and "/one-piece-episode-1100/" both give
+// "one-piece"
+func SeriesSlugFromURL(rawURL string) string {
+	return episodeSuffix.ReplaceAllString(SlugFromURL(rawURL), "")
+}
+
+// pageURL returns the canonical URL of a parsed page, if it declares one
+func pageURL(doc *goquery.Document) string {
+	if href := doc.Find("link[rel='canonical']").AttrOr("href", ""); href != "" {
+		return href
+	}
+	return doc.Find("meta[property='og:url']").AttrOr("content", "")
+}
+
+// newEpisode builds an Episode with its kind/number parsed from the title,
+// falling back to the URL slug
+func newEpisode(title, endpoint, seriesSlug string) Episode {
+	ep := Episode{
+		Title:      title,
+		Endpoint:   endpoint,
+		SeriesSlug: seriesSlug,
+	}
+	ep.Kind, ep.Number = ParseEpisodeNumber(title)
+	if ep.Kind == "" || (ep.Number == "" && ep.Kind != KindMovie) {
+		if kind, number := ParseEpisodeNumber(SlugFromURL(endpoint)); kind != "" {
+			ep.Kind, ep.Number = kind, number
+		}
+	}
+	return ep
+}
@@ -0,0 +1,51 @@
+package winbu
+
+import "testing"
+
+func TestParseEpisodeNumber(t *testing.T) {
+	tests := []struct {
+		text       string
+		kind, want string
+	}{
+		{"Episode 12", KindEpisode, "12"},
+		{"One Piece Episode 1100 Subtitle Indonesia", KindEpisode, "1100"},
+		{"one-piece-episode-1100", KindEpisode, "1100"},
+		{"Eps 07", KindEpisode, "7"},
+		{"ep.3", KindEpisode, "3"},
+		{"Episode 0", KindEpisode, "0"},
+		{"Episode 12.5", KindEpisode, "12.5"},
+		{"Special A Episode 5", KindEpisode, "5"},
+		{"Kimetsu no Yaiba OVA 2", KindOVA, "2"},
+		{"ONA", KindOVA, ""},
+		{"Spesial 1", KindSpecial, "1"},
+		{"Special", KindSpecial, ""},
+		{"Jujutsu Kaisen 0 Movie", KindMovie, ""},
+		{"Movie 3", KindMovie, "3"},
+		{"Sousou no Frieren", "", ""},
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		kind, number := ParseEpisodeNumber(tt.text)
+		if kind != tt.kind || number != tt.want {
+			t.Errorf("ParseEpisodeNumber(%q) = (%q, %q), want (%q, %q)", tt.text, kind, number, tt.kind, tt.want)
+		}
+	}
+}
+
+func TestSeriesSlugFromURL(t *testing.T) {
+	tests := []struct {
+		url, want string
+	}{
+		{"https://winbu.net/anime/one-piece/", "one-piece"},
+		{"https://winbu.net/one-piece-episode-1100/", "one-piece"},
+		{"https://winbu.net/kimetsu-no-yaiba-ova-2-subtitle-indonesia/", "kimetsu-no-yaiba"},
+		{"https://winbu.net/film/suzume/", "suzume"},
+		{"https://winbu.net/", ""},
+	}
+	for _, tt := range tests {
+		if got := SeriesSlugFromURL(tt.url); got != tt.want {
+			t.Errorf("SeriesSlugFromURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
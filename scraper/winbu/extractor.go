@@ -0,0 +1,258 @@
+package winbu
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Fetcher retrieves a page body; referer is sent when non-empty.
+// Extractors stay free of HTTP details so the service can reuse its client.
+type Fetcher func(pageURL, referer string) ([]byte, error)
+
+// EmbedResult is everything an extractor found on an embed page
+type EmbedResult struct {
+	Sources   []MediaSource
+	Subtitles []Subtitle
+}
+
+// Extractor pulls direct media out of a third-party embed host
+type Extractor interface {
+	Name() string
+	Extract(embedURL *url.URL, fetch Fetcher) (*EmbedResult, error)
+}
+
+// extractors maps embed hostnames (without "www.") to their extractor
+var extractors = map[string]Extractor{}
+
+// RegisterExtractor registers e for the given hostnames; subdomains match too
+func RegisterExtractor(e Extractor, hosts ...string) {
+	for _, h := range hosts {
+		extractors[strings.TrimPrefix(strings.ToLower(h), "www.")] = e
+	}
+}
+
+// ExtractorFor returns the extractor registered for host, walking up parent
+// domains, and falls back to the generic page scanner
+func ExtractorFor(host string) Extractor {
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	for host != "" {
+		if e, ok := extractors[host]; ok {
+			return e
+		}
+		dot := strings.IndexByte(host, '.')
+		if dot == -1 {
+			break
+		}
+		host = host[dot+1:]
+	}
+	return genericExtractor{}
+}
+
+// ExtractEmbed resolves an iframe src into direct media sources. When the
+// page has no playable source the error comes with a partial result still
+// carrying any subtitles that were found.
+func ExtractEmbed(embedURL string, fetch Fetcher) (*EmbedResult, error) {
+	if strings.HasPrefix(embedURL, "//") {
+		embedURL = "https:" + embedURL
+	}
+	u, err := url.Parse(embedURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid embed url: %v", err)
+	}
+
+	// Some servers hand out the media file directly instead of an embed page
+	if kind := mediaType(u.Path); kind != "" {
+		return &EmbedResult{Sources: []MediaSource{{
+			Host:    u.Host,
+			URL:     u.String(),
+			Type:    kind,
+			Quality: guessQuality(u.String()),
+		}}}, nil
+	}
+
+	e := ExtractorFor(u.Host)
+	result, err := e.Extract(u, fetch)
+	if err != nil {
+		return nil, fmt.Errorf("%s extractor: %v", e.Name(), err)
+	}
+
+	// Normalise what every extractor returns
+	seen := make(map[string]bool)
+	sources := result.Sources[:0]
+	for _, src := range result.Sources {
+		src.URL = resolveRef(u, src.URL)
+		if src.URL == "" || seen[src.URL] {
+			continue
+		}
+		seen[src.URL] = true
+		if src.Host == "" {
+			src.Host = u.Host
+		}
+		if src.Type == "" {
+			src.Type = mediaType(src.URL)
+			if src.Type == "" {
+				src.Type = "mp4"
+			}
+		}
+		if src.Quality == "" {
+			src.Quality = guessQuality(src.Label + " " + src.URL)
+		}
+		sources = append(sources, src)
+	}
+	result.Sources = sources
+
+	seenSubs := make(map[string]bool)
+	subtitles := result.Subtitles[:0]
+	for _, sub := range result.Subtitles {
+		sub.URL = resolveRef(u, sub.URL)
+		if sub.URL == "" || seenSubs[sub.URL] {
+			continue
+		}
+		seenSubs[sub.URL] = true
+		if sub.Format == "" {
+			sub.Format = SubtitleFormat(sub.URL)
+		}
+		if sub.Language == "" {
+			sub.Language = guessLanguage(sub.Label)
+		}
+		if sub.Language == "" {
+			// Only the filename: query strings like "?id=" are not languages
+			if su, err := url.Parse(sub.URL); err == nil {
+				sub.Language = guessLanguage(path.Base(su.Path))
+			}
+		}
+		subtitles = append(subtitles, sub)
+	}
+	result.Subtitles = subtitles
+
+	if len(result.Sources) == 0 {
+		return result, fmt.Errorf("%s extractor: no media found on %s", e.Name(), u.Host)
+	}
+	return result, nil
+}
+
+// mediaType classifies a URL or path as "hls", "mp4" or "" (not media)
+func mediaType(s string) string {
+	lower := strings.ToLower(s)
+	if i := strings.IndexAny(lower, "?#"); i != -1 {
+		lower = lower[:i]
+	}
+	switch {
+	case strings.HasSuffix(lower, ".m3u8"):
+		return "hls"
+	case strings.HasSuffix(lower, ".mp4"), strings.HasSuffix(lower, ".mkv"), strings.HasSuffix(lower, ".webm"):
+		return "mp4"
+	}
+	return ""
+}
+
+// SubtitleFormat classifies a subtitle URL or filename as "vtt", "srt",
+// "ass" or "" (not a subtitle)
+func SubtitleFormat(s string) string {
+	lower := strings.ToLower(s)
+	if i := strings.IndexAny(lower, "?#"); i != -1 {
+		lower = lower[:i]
+	}
+	switch {
+	case strings.HasSuffix(lower, ".vtt"):
+		return "vtt"
+	case strings.HasSuffix(lower, ".srt"):
+		return "srt"
+	case strings.HasSuffix(lower, ".ass"), strings.HasSuffix(lower, ".ssa"):
+		return "ass"
+	}
+	return ""
+}
+
+// languagePatterns map label fragments to ISO 639-1 codes; Indonesian first
+// since that is what winbu's audience wants
+var languagePatterns = []struct {
+	code    string
+	pattern *regexp.Regexp
+}{
+	{"id", regexp.MustCompile(`(?i)\b(indonesia|indonesian|bahasa|indo|ind|id)\b`)},
+	{"en", regexp.MustCompile(`(?i)\b(english|inggris|eng|en)\b`)},
+	{"ms", regexp.MustCompile(`(?i)\b(malay|melayu|malaysia|ms)\b`)},
+	{"ja", regexp.MustCompile(`(?i)\b(japanese|jepang|jpn|ja)\b`)},
+}
+
+// guessLanguage finds a language code in a track label or URL
+func guessLanguage(text string) string {
+	for _, l := range languagePatterns {
+		if l.pattern.MatchString(text) {
+			return l.code
+		}
+	}
+	return ""
+}
+
+var qualityPattern = regexp.MustCompile(`(?i)\b(2160|1440|1080|720|480|360|240)p?\b`)
+
+// guessQuality finds a resolution label such as "720p" in text
+func guessQuality(text string) string {
+	if m := qualityPattern.FindStringSubmatch(text); m != nil {
+		return m[1] + "p"
+	}
+	return ""
+}
+
+// resolveRef makes extracted URLs absolute and unescapes JSON slashes
+func resolveRef(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(strings.ReplaceAll(ref, `\/`, "/"))
+	if ref == "" {
+		return ""
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+	return base.ResolveReference(r).String()
+}
+
+// packerPattern matches Dean Edwards' P.A.C.K.E.R. payloads used by most
+// video hosts to hide their player config
+var packerPattern = regexp.MustCompile(`}\('(.*)',\s*(\d+),\s*(\d+),\s*'(.*?)'\.split\('\|'\)`)
+
+var packerWord = regexp.MustCompile(`\b\w+\b`)
+
+// unpackScripts returns the unpacked source of every packed script in page
+func unpackScripts(page string) []string {
+	var out []string
+	for _, m := range packerPattern.FindAllStringSubmatch(page, -1) {
+		payload := strings.ReplaceAll(m[1], `\'`, `'`)
+		radix, _ := strconv.Atoi(m[2])
+		count, _ := strconv.Atoi(m[3])
+		words := strings.Split(m[4], "|")
+		if radix < 2 || radix > 62 || count != len(words) {
+			continue
+		}
+
+		out = append(out, packerWord.ReplaceAllStringFunc(payload, func(w string) string {
+			idx := decodeBase(w, radix)
+			if idx >= 0 && idx < len(words) && words[idx] != "" {
+				return words[idx]
+			}
+			return w
+		}))
+	}
+	return out
+}
+
+const base62 = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// decodeBase parses s in the packer's base-N alphabet, -1 on invalid input
+func decodeBase(s string, radix int) int {
+	n := 0
+	for _, c := range s {
+		d := strings.IndexRune(base62[:radix], c)
+		if d == -1 {
+			return -1
+		}
+		n = n*radix + d
+	}
+	return n
+}
@@ -0,0 +1,211 @@
+package winbu
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+func init() {
+	RegisterExtractor(pixeldrainExtractor{}, "pixeldrain.com", "pixeldra.in")
+	RegisterExtractor(bloggerExtractor{}, "blogger.com", "blogspot.com")
+}
+
+// genericExtractor scans an embed page (and any packed scripts in it) for
+// JWPlayer/Plyr style source lists, <source>/<video> tags and bare media URLs
+type genericExtractor struct{}
+
+func (genericExtractor) Name() string { return "generic" }
+
+var (
+	// sources: [{file:"...", label:"720p"}, ...] (JWPlayer, Clappr, Plyr configs)
+	sourceObjectPattern = regexp.MustCompile(`\{[^{}]*?["']?(?:file|src)["']?\s*:\s*["']([^"']+)["'][^{}]*?\}`)
+	labelPattern        = regexp.MustCompile(`["']?(?:label|res|size)["']?\s*:\s*["']?([^"',}]+)`)
+	// Bare .m3u8 / .mp4 URLs anywhere in the page
+	mediaURLPattern = regexp.MustCompile(`(?:https?:)?(?:\\?/){2}[^"'\s<>()]+?\.(?:m3u8|mp4)(?:\?[^"'\s<>()]*)?`)
+)
+
+func (genericExtractor) Extract(embedURL *url.URL, fetch Fetcher) (*EmbedResult, error) {
+	body, err := fetch(embedURL.String(), embedURL.Scheme+"://"+embedURL.Host+"/")
+	if err != nil {
+		return nil, err
+	}
+	page := string(body)
+	scripts := append([]string{page}, unpackScripts(page)...)
+
+	result := &EmbedResult{}
+	referer := map[string]string{"Referer": embedURL.Scheme + "://" + embedURL.Host + "/"}
+
+	for _, text := range scripts {
+		for _, m := range sourceObjectPattern.FindAllStringSubmatch(text, -1) {
+			if mediaType(m[1]) == "" && !strings.Contains(m[1], "m3u8") {
+				continue
+			}
+			src := MediaSource{URL: html.UnescapeString(m[1]), Headers: referer}
+			if lm := labelPattern.FindStringSubmatch(m[0]); lm != nil {
+				src.Label = strings.TrimSpace(lm[1])
+			}
+			if strings.Contains(m[1], "m3u8") {
+				src.Type = "hls"
+			}
+			result.Sources = append(result.Sources, src)
+		}
+
+		for _, m := range mediaURLPattern.FindAllString(text, -1) {
+			result.Sources = append(result.Sources, MediaSource{URL: html.UnescapeString(m), Headers: referer})
+		}
+	}
+
+	// <video src>, <source src> and og:video meta tags
+	for _, attr := range htmlMediaAttrs(page) {
+		result.Sources = append(result.Sources, MediaSource{URL: attr.url, Label: attr.label, Headers: referer})
+	}
+
+	for _, text := range scripts {
+		for _, sub := range scriptSubtitles(text) {
+			sub.Headers = referer
+			result.Subtitles = append(result.Subtitles, sub)
+		}
+	}
+	for _, sub := range trackTags(page) {
+		sub.Headers = referer
+		result.Subtitles = append(result.Subtitles, sub)
+	}
+
+	return result, nil
+}
+
+type mediaAttr struct {
+	url   string
+	label string
+}
+
+var (
+	sourceTagPattern = regexp.MustCompile(`(?is)<(?:source|video)\b[^>]*?\bsrc=["']([^"']+)["'][^>]*>`)
+	tagLabelPattern  = regexp.MustCompile(`(?i)\b(?:label|size|res|title)=["']([^"']+)["']`)
+	ogVideoPattern   = regexp.MustCompile(`(?i)<meta[^>]+property=["']og:video(?::url|:secure_url)?["'][^>]+content=["']([^"']+)["']`)
+)
+
+// htmlMediaAttrs collects media URLs from tags rather than scripts
+func htmlMediaAttrs(page string) []mediaAttr {
+	var attrs []mediaAttr
+	for _, m := range sourceTagPattern.FindAllStringSubmatch(page, -1) {
+		if strings.HasPrefix(m[1], "blob:") {
+			continue
+		}
+		a := mediaAttr{url: html.UnescapeString(m[1])}
+		if lm := tagLabelPattern.FindStringSubmatch(m[0]); lm != nil {
+			a.label = lm[1]
+		}
+		attrs = append(attrs, a)
+	}
+	for _, m := range ogVideoPattern.FindAllStringSubmatch(page, -1) {
+		attrs = append(attrs, mediaAttr{url: html.UnescapeString(m[1])})
+	}
+	return attrs
+}
+
+var (
+	// tracks: [{file:"id.vtt", label:"Indonesia", kind:"captions"}]
+	subtitleObjectPattern = regexp.MustCompile(`\{[^{}]*?["']?(?:file|src)["']?\s*:\s*["']([^"']+\.(?:vtt|srt|ass|ssa)(?:\?[^"']*)?)["'][^{}]*?\}`)
+	trackTagPattern       = regexp.MustCompile(`(?is)<track\b[^>]*>`)
+	trackAttrPattern      = regexp.MustCompile(`(?i)\b(src|srclang|label)=["']([^"']*)["']`)
+)
+
+// scriptSubtitles finds subtitle tracks in player configs
+func scriptSubtitles(text string) []Subtitle {
+	var subs []Subtitle
+	for _, m := range subtitleObjectPattern.FindAllStringSubmatch(text, -1) {
+		sub := Subtitle{URL: html.UnescapeString(m[1])}
+		if lm := labelPattern.FindStringSubmatch(m[0]); lm != nil {
+			sub.Label = strings.TrimSpace(lm[1])
+		}
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
+// trackTags reads <track src srclang label> elements
+func trackTags(page string) []Subtitle {
+	var subs []Subtitle
+	for _, tag := range trackTagPattern.FindAllString(page, -1) {
+		var sub Subtitle
+		for _, m := range trackAttrPattern.FindAllStringSubmatch(tag, -1) {
+			value := html.UnescapeString(m[2])
+			switch strings.ToLower(m[1]) {
+			case "src":
+				sub.URL = value
+			case "srclang":
+				sub.Language = strings.ToLower(value)
+			case "label":
+				sub.Label = value
+			}
+		}
+		if sub.URL != "" {
+			subs = append(subs, sub)
+		}
+	}
+	return subs
+}
+
+// pixeldrainExtractor maps /u/<id> share links to the file API, no fetch needed
+type pixeldrainExtractor struct{}
+
+func (pixeldrainExtractor) Name() string { return "pixeldrain" }
+
+func (pixeldrainExtractor) Extract(embedURL *url.URL, fetch Fetcher) (*EmbedResult, error) {
+	parts := strings.Split(strings.Trim(embedURL.Path, "/"), "/")
+	if len(parts) < 2 || (parts[0] != "u" && parts[0] != "api") {
+		return nil, fmt.Errorf("unrecognised pixeldrain url %s", embedURL.Path)
+	}
+	id := parts[len(parts)-1]
+	return &EmbedResult{Sources: []MediaSource{{
+		URL:  "https://pixeldrain.com/api/file/" + id,
+		Type: "mp4",
+	}}}, nil
+}
+
+// bloggerExtractor reads the VIDEO_CONFIG JSON of blogger.com/video.g embeds
+type bloggerExtractor struct{}
+
+func (bloggerExtractor) Name() string { return "blogger" }
+
+var bloggerConfigPattern = regexp.MustCompile(`(?s)var\s+VIDEO_CONFIG\s*=\s*(\{.*?\})\s*</script>`)
+
+// bloggerFormats maps YouTube-style itags used by Blogger to resolutions
+var bloggerFormats = map[int]string{18: "360p", 22: "720p", 37: "1080p"}
+
+func (bloggerExtractor) Extract(embedURL *url.URL, fetch Fetcher) (*EmbedResult, error) {
+	body, err := fetch(embedURL.String(), "https://www.blogger.com/")
+	if err != nil {
+		return nil, err
+	}
+
+	m := bloggerConfigPattern.FindSubmatch(body)
+	if m == nil {
+		return nil, fmt.Errorf("VIDEO_CONFIG not found")
+	}
+
+	var config struct {
+		Streams []struct {
+			PlayURL  string `json:"play_url"`
+			FormatID int    `json:"format_id"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(m[1], &config); err != nil {
+		return nil, fmt.Errorf("decode VIDEO_CONFIG: %v", err)
+	}
+
+	result := &EmbedResult{}
+	for _, s := range config.Streams {
+		result.Sources = append(result.Sources, MediaSource{
+			URL:     s.PlayURL,
+			Type:    "mp4",
+			Quality: bloggerFormats[s.FormatID],
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,202 @@
+package winbu
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ParseStatus maps winbu's status labels to a Status* constant
+func ParseStatus(text string) string {
+	lower := strings.ToLower(text)
+	switch {
+	case strings.Contains(lower, "ongoing"), strings.Contains(lower, "airing"), strings.Contains(lower, "berlangsung"):
+		return StatusOngoing
+	case strings.Contains(lower, "complete"), strings.Contains(lower, "finished"), strings.Contains(lower, "tamat"), strings.Contains(lower, "selesai"):
+		return StatusCompleted
+	case strings.Contains(lower, "upcoming"), strings.Contains(lower, "not yet"), strings.Contains(lower, "segera"):
+		return StatusUpcoming
+	}
+	return ""
+}
+
+var (
+	hoursPattern   = regexp.MustCompile(`(?i)(\d+)\s*(?:hours?|hrs?|h|jam)\b`)
+	minutesPattern = regexp.MustCompile(`(?i)(\d+)\s*(?:minutes?|mins?|m|menit)\b`)
+	clockPattern   = regexp.MustCompile(`\b(\d{1,2}):(\d{2})(?::\d{2})?\b`)
+	numberPattern  = regexp.MustCompile(`\d+`)
+)
+
+// ParseDurationMinutes reads "24 min", "1 hr 30 min", "1 jam 50 menit",
+// "01:30:00" or a bare "24" as minutes, 0 when unknown
+func ParseDurationMinutes(text string) int {
+	minutes := 0
+	found := false
+	if m := hoursPattern.FindStringSubmatch(text); m != nil {
+		h, _ := strconv.Atoi(m[1])
+		minutes += h * 60
+		found = true
+	}
+	if m := minutesPattern.FindStringSubmatch(text); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		minutes += n
+		found = true
+	}
+	if found {
+		return minutes
+	}
+	if m := clockPattern.FindStringSubmatch(text); m != nil {
+		h, _ := strconv.Atoi(m[1])
+		n, _ := strconv.Atoi(m[2])
+		return h*60 + n
+	}
+	if m := numberPattern.FindString(text); m != "" {
+		n, _ := strconv.Atoi(m)
+		return n
+	}
+	return 0
+}
+
+// months maps Indonesian and English month names/abbreviations
+var months = map[string]time.Month{
+	"januari": time.January, "january": time.January, "jan": time.January,
+	"februari": time.February, "february": time.February, "feb": time.February, "pebruari": time.February,
+	"maret": time.March, "march": time.March, "mar": time.March,
+	"april": time.April, "apr": time.April,
+	"mei": time.May, "may": time.May,
+	"juni": time.June, "june": time.June, "jun": time.June,
+	"juli": time.July, "july": time.July, "jul": time.July,
+	"agustus": time.August, "august": time.August, "agu": time.August, "agt": time.August, "aug": time.August,
+	"september": time.September, "sep": time.September, "sept": time.September,
+	"oktober": time.October, "october": time.October, "okt": time.October, "oct": time.October,
+	"november": time.November, "nov": time.November, "nop": time.November,
+	"desember": time.December, "december": time.December, "des": time.December, "dec": time.December,
+}
+
+var (
+	isoDatePattern = regexp.MustCompile(`\b(\d{4})-(\d{1,2})-(\d{1,2})\b`)
+	dmyPattern     = regexp.MustCompile(`\b(\d{1,2})[/.](\d{1,2})[/.](\d{4})\b`)
+	yearPattern    = regexp.MustCompile(`\b(19\d{2}|20\d{2})\b`)
+	wordPattern    = regexp.MustCompile(`[A-Za-z]+|\d+`)
+	rangeSeparator = regexp.MustCompile(`(?i)\s+(?:to|s/d|sampai|hingga|-|–)\s+`)
+)
+
+// ParseReleaseDate parses release dates such as "12 Januari 2024",
+// "Jan 12, 2024", "2024-01-12" or "12/01/2024". For aired ranges
+// ("Oct 2, 2023 to ?") the start is used. A date with only month and year
+// falls on the 1st. ok is false when no month and year could be found;
+// year is still returned when present on its own.
+func ParseReleaseDate(text string) (date time.Time, year int, ok bool) {
+	text = rangeSeparator.Split(strings.TrimSpace(text), 2)[0]
+
+	if m := isoDatePattern.FindStringSubmatch(text); m != nil {
+		return makeDate(m[1], m[2], m[3])
+	}
+	if m := dmyPattern.FindStringSubmatch(text); m != nil {
+		return makeDate(m[3], m[2], m[1])
+	}
+
+	var month time.Month
+	day := 0
+	for _, w := range wordPattern.FindAllString(text, -1) {
+		if mo, found := months[strings.ToLower(w)]; found && month == 0 {
+			month = mo
+			continue
+		}
+		if n, err := strconv.Atoi(w); err == nil {
+			switch {
+			case n >= 1900 && n < 2100:
+				year = n
+			case n >= 1 && n <= 31 && day == 0:
+				day = n
+			}
+		}
+	}
+	if year == 0 || month == 0 {
+		return time.Time{}, year, false
+	}
+	if day == 0 {
+		day = 1
+	}
+	return dateOf(year, month, day)
+}
+
+func makeDate(y, m, d string) (time.Time, int, bool) {
+	year, _ := strconv.Atoi(y)
+	month, _ := strconv.Atoi(m)
+	day, _ := strconv.Atoi(d)
+	return dateOf(year, time.Month(month), day)
+}
+
+// dateOf rejects days the month does not have, which time.Date would roll
+// over into the next month (2024-02-31 becoming March 2)
+func dateOf(year int, month time.Month, day int) (time.Time, int, bool) {
+	if month < time.January || month > time.December || day < 1 {
+		return time.Time{}, year, false
+	}
+	t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	if t.Month() != month || t.Day() != day {
+		return time.Time{}, year, false
+	}
+	return t, year, true
+}
+
+// seasonNames maps English and Indonesian season names
+var seasonNames = map[string]string{
+	"winter": "winter", "dingin": "winter",
+	"spring": "spring", "semi": "spring",
+	"summer": "summer", "panas": "summer",
+	"fall": "fall", "autumn": "fall", "gugur": "fall",
+}
+
+// ParseSeason reads "Fall 2023" or "Musim Gugur 2023" into ("fall", 2023)
+func ParseSeason(text string) (season string, year int) {
+	for _, w := range wordPattern.FindAllString(strings.ToLower(text), -1) {
+		if s, ok := seasonNames[w]; ok && season == "" {
+			season = s
+		}
+	}
+	if m := yearPattern.FindString(text); m != "" {
+		year, _ = strconv.Atoi(m)
+	}
+	return season, year
+}
+
+// SeasonOf returns the anime broadcast season a date falls in
+func SeasonOf(t time.Time) string {
+	switch t.Month() {
+	case time.January, time.February, time.March:
+		return "winter"
+	case time.April, time.May, time.June:
+		return "spring"
+	case time.July, time.August, time.September:
+		return "summer"
+	}
+	return "fall"
+}
+
+// ParseScore reads "8.5", "8,5" or "8.5/10" as a float, 0 when unrated
+func ParseScore(text string) float64 {
+	text = strings.TrimSpace(strings.ReplaceAll(text, ",", "."))
+	if i := strings.IndexAny(text, "/ "); i != -1 {
+		text = text[:i]
+	}
+	score, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0
+	}
+	return score
+}
+
+// splitTitles splits an alternative titles row on "," and ";" ("/" is part
+// of titles like "Fate/Zero")
+func splitTitles(text string) []string {
+	var titles []string
+	for _, t := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ';' }) {
+		if t = strings.TrimSpace(t); t != "" {
+			titles = append(titles, t)
+		}
+	}
+	return titles
+}
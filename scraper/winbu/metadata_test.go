@@ -0,0 +1,73 @@
+package winbu
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMakeDate(t *testing.T) {
+	tests := []struct {
+		y, m, d  string
+		want     string // "" when the date is invalid
+		wantYear int
+	}{
+		{"2024", "01", "12", "2024-01-12", 2024},
+		{"2024", "2", "29", "2024-02-29", 2024},
+		{"2023", "2", "29", "", 2023},
+		{"2024", "02", "31", "", 2024},
+		{"2024", "04", "31", "", 2024},
+		{"2024", "12", "31", "2024-12-31", 2024},
+		{"2024", "13", "01", "", 2024},
+		{"2024", "00", "10", "", 2024},
+		{"2024", "05", "00", "", 2024},
+	}
+
+	for _, tt := range tests {
+		date, year, ok := makeDate(tt.y, tt.m, tt.d)
+		if year != tt.wantYear {
+			t.Errorf("makeDate(%s, %s, %s) year = %d, want %d", tt.y, tt.m, tt.d, year, tt.wantYear)
+		}
+		if tt.want == "" {
+			if ok {
+				t.Errorf("makeDate(%s, %s, %s) = %s, want no date", tt.y, tt.m, tt.d, date.Format(time.DateOnly))
+			}
+			continue
+		}
+		if !ok || date.Format(time.DateOnly) != tt.want {
+			t.Errorf("makeDate(%s, %s, %s) = %s, %v, want %s", tt.y, tt.m, tt.d, date.Format(time.DateOnly), ok, tt.want)
+		}
+	}
+}
+
+func TestParseReleaseDate(t *testing.T) {
+	tests := []struct {
+		text     string
+		want     string // "" when no full date is found
+		wantYear int
+	}{
+		{"12 Januari 2024", "2024-01-12", 2024},
+		{"Jan 12, 2024", "2024-01-12", 2024},
+		{"2024-01-12", "2024-01-12", 2024},
+		{"12/01/2024", "2024-01-12", 2024},
+		{"Oct 2, 2023 to ?", "2023-10-02", 2023},
+		{"Maret 2022", "2022-03-01", 2022},
+		{"31 Februari 2024", "", 2024},
+		{"31/02/2024", "", 2024},
+		{"2019", "", 2019},
+		{"?", "", 0},
+	}
+
+	for _, tt := range tests {
+		date, year, ok := ParseReleaseDate(tt.text)
+		if year != tt.wantYear {
+			t.Errorf("ParseReleaseDate(%q) year = %d, want %d", tt.text, year, tt.wantYear)
+		}
+		got := ""
+		if ok {
+			got = date.Format(time.DateOnly)
+		}
+		if got != tt.want {
+			t.Errorf("ParseReleaseDate(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
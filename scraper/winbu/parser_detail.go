@@ -1,6 +1,7 @@
 package winbu
 
 import (
+	"strconv"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
@@ -26,7 +27,7 @@ func ParseAnimeDetail(doc *goquery.Document) (*AnimeDetail, error) {
 	detail.Synopsis = strings.TrimSpace(container.Find(".ml-mask .mli-desc").Text())
 
 	// Score
-	detail.Score = strings.TrimSpace(container.Find(".ml-mask .mli-mvi span[itemprop='ratingValue']").Text())
+	detail.Score = ParseScore(container.Find(".ml-mask .mli-mvi span[itemprop='ratingValue']").Text())
 
 	// Genres
 	container.Find(".ml-mask .mli-mvi a[itemprop='genre']").Each(func(i int, s *goquery.Selection) {
@@ -34,44 +35,84 @@ func ParseAnimeDetail(doc *goquery.Document) (*AnimeDetail, error) {
 	})
 
 	// Episodes
+	seriesSlug := SeriesSlugFromURL(pageURL(doc))
 	doc.Find(".tvseason .les-content a").Each(func(i int, s *goquery.Selection) {
 		url, exists := s.Attr("href")
 		if exists {
-			detail.Episodes = append(detail.Episodes, Episode{
-				Title:    strings.TrimSpace(s.Text()),
-				Endpoint: url,
-			})
+			detail.Episodes = append(detail.Episodes, newEpisode(strings.TrimSpace(s.Text()), url, seriesSlug))
 		}
 	})
 
-	// Other Metadata
+	// Info rows: "Status : Ongoing", "Duration : 24 min", ...
 	container.Find(".mli-mvi").Each(func(i int, s *goquery.Selection) {
-		text := strings.TrimSpace(s.Text())
-		if strings.Contains(text, "Status :") {
-			detail.Metadata["Status"] = strings.TrimSpace(strings.Replace(text, "Status :", "", 1))
-		} else if strings.Contains(text, "Duration :") {
-			detail.Metadata["Duration"] = strings.TrimSpace(strings.Replace(text, "Duration :", "", 1))
-		} else if strings.Contains(text, "Negara :") {
-			detail.Metadata["Country"] = strings.TrimSpace(strings.Replace(text, "Negara :", "", 1))
-		} else if strings.Contains(text, "Credit :") {
-			detail.Metadata["Credit"] = strings.TrimSpace(strings.Replace(text, "Credit :", "", 1))
-		} else if strings.Contains(text, "Kualitas :") {
-			detail.Metadata["Quality"] = strings.TrimSpace(strings.Replace(text, "Kualitas :", "", 1))
-		} else if strings.Contains(text, "Released :") { // Or Published in some cases? Need to verify selector or just generic handler
-			// Check if it's the date icon line
-		} else if strings.Contains(text, "Date Released :") || strings.Contains(s.Find("i.fa-calendar").AttrOr("class", ""), "fa-calendar") {
-			// Sometimes date is just text next to calendar icon
-			detail.Metadata["Released"] = strings.TrimSpace(s.Text())
+		// The score and genre rows are handled above
+		if s.Find("span[itemprop='ratingValue'], a[itemprop='genre']").Length() > 0 {
+			return
 		}
-
-		// Skip Encode as requested
-		if strings.Contains(text, "Encode :") {
+		text := strings.Join(strings.Fields(s.Text()), " ")
+		key, value, ok := strings.Cut(text, ":")
+		if !ok {
+			// The release date is sometimes only a calendar icon and the date
+			if s.Find("i.fa-calendar").Length() > 0 {
+				applyDetailField(detail, "Released", text)
+			}
 			return
 		}
-
-		// Generic Fallback for other fields if needed, or specific mapped ones above.
-		// Given the request, specific mapping is safer.
+		applyDetailField(detail, strings.TrimSpace(key), strings.TrimSpace(value))
 	})
 
+	if detail.TotalEpisodes == 0 && detail.Status == StatusCompleted {
+		detail.TotalEpisodes = len(detail.Episodes)
+	}
+
 	return detail, nil
 }
+
+// applyDetailField stores one info row in its typed field; rows without one
+// are kept in Metadata under their original key
+func applyDetailField(detail *AnimeDetail, key, value string) {
+	if value == "" {
+		return
+	}
+	switch strings.ToLower(key) {
+	case "status":
+		detail.Status = ParseStatus(value)
+	case "type", "tipe":
+		detail.Type = value
+	case "duration", "durasi":
+		detail.DurationMinutes = ParseDurationMinutes(value)
+	case "negara", "country":
+		detail.Country = value
+	case "credit", "studio", "studios":
+		detail.Studio = value
+	case "kualitas", "quality":
+		detail.Quality = value
+	case "released", "date released", "rilis", "tanggal rilis", "aired", "tayang":
+		date, year, ok := ParseReleaseDate(value)
+		if ok {
+			detail.ReleaseDate = &date
+			if detail.Season == "" {
+				detail.Season = SeasonOf(date)
+			}
+		}
+		if detail.Year == 0 {
+			detail.Year = year
+		}
+	case "season", "musim", "premiered":
+		season, year := ParseSeason(value)
+		if season != "" {
+			detail.Season = season
+		}
+		if year != 0 {
+			detail.Year = year
+		}
+	case "total episode", "total episodes", "episodes", "episode", "jumlah episode", "total eps":
+		detail.TotalEpisodes, _ = strconv.Atoi(numberPattern.FindString(value))
+	case "alternative", "alternative title", "alternative titles", "judul alternatif", "synonyms", "other name", "judul lain", "english", "japanese":
+		detail.AltTitles = append(detail.AltTitles, splitTitles(value)...)
+	case "encode":
+		// Encoder credit, not useful to clients
+	default:
+		detail.Metadata[key] = value
+	}
+}
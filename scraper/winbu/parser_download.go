@@ -0,0 +1,170 @@
+package winbu
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// parseDownloadLinks reads the download box of an episode page. Winbu lists
+// links as "<b>MP4</b><ul><li><strong>720p</strong> <a>Server</a>...</li></ul>",
+// so quality/format/encoding come from the surrounding row and section
+// heading rather than the anchor text.
+func parseDownloadLinks(doc *goquery.Document) []DownloadLink {
+	var links []DownloadLink
+
+	// Strategy 1: .download-eps
+	doc.Find(".download-eps a").Each(func(i int, s *goquery.Selection) {
+		href := strings.TrimSpace(s.AttrOr("href", ""))
+		if href == "" || strings.HasPrefix(href, "javascript") || strings.HasPrefix(href, "#") {
+			return
+		}
+
+		server := strings.TrimSpace(s.Text())
+		context := sectionHeading(s) + " " + rowLabel(s) + " " + server
+		quality, format, encoding := classifyDownload(context)
+
+		links = append(links, DownloadLink{
+			Server:   server,
+			URL:      href,
+			Quality:  quality,
+			Format:   format,
+			Encoding: encoding,
+		})
+	})
+
+	// Strategy 2: #download container
+	if len(links) == 0 {
+		doc.Find("#download a").Each(func(i int, s *goquery.Selection) {
+			href := strings.TrimSpace(s.AttrOr("href", ""))
+			if href == "" {
+				return
+			}
+			server := strings.TrimSpace(s.Text())
+			quality, format, encoding := classifyDownload(rowLabel(s) + " " + server)
+			links = append(links, DownloadLink{
+				Server:   server,
+				URL:      href,
+				Quality:  quality,
+				Format:   format,
+				Encoding: encoding,
+			})
+		})
+	}
+
+	return links
+}
+
+// rowLabel returns the text of the link's row without the anchors themselves,
+// e.g. "720p x265" for "<li><strong>720p x265</strong><a>GDrive</a></li>"
+func rowLabel(s *goquery.Selection) string {
+	row := s.Closest("li, tr, p")
+	if row.Length() == 0 {
+		row = s.Parent()
+	}
+	label := row.Clone()
+	label.Find("a").Remove()
+	return strings.Join(strings.Fields(label.Text()), " ")
+}
+
+// sectionHeading returns the nearest heading above the link's list, which
+// usually names the container format ("MP4", "MKV", "x265 [Mini]")
+func sectionHeading(s *goquery.Selection) string {
+	list := s.Closest("ul, table")
+	if list.Length() == 0 {
+		return ""
+	}
+	heading := list.PrevAllFiltered("h2, h3, h4, h5, p, b, strong, span, div").First()
+	if heading.Find("a").Length() > 0 {
+		return ""
+	}
+	return strings.TrimSpace(heading.Text())
+}
+
+var (
+	formatPattern   = regexp.MustCompile(`(?i)\b(mp4|mkv|webm|avi)\b`)
+	encodingPattern = regexp.MustCompile(`(?i)\b(x265|x264|h\.?265|h\.?264|hevc|avc|av1)\b`)
+	hdPattern       = regexp.MustCompile(`(?i)\b(fhd|full\s*hd|hd|sd)\b`)
+)
+
+// classifyDownload extracts quality ("720p"), container format ("MP4") and
+// video encoding ("x265") from a download row's text
+func classifyDownload(text string) (quality, format, encoding string) {
+	quality = guessQuality(text)
+	if quality == "" {
+		if m := hdPattern.FindStringSubmatch(text); m != nil {
+			switch strings.ToLower(strings.Join(strings.Fields(m[1]), "")) {
+			case "fhd", "fullhd":
+				quality = "1080p"
+			case "hd":
+				quality = "720p"
+			case "sd":
+				quality = "480p"
+			}
+		}
+	}
+
+	if m := formatPattern.FindStringSubmatch(text); m != nil {
+		format = strings.ToUpper(m[1])
+	}
+
+	if m := encodingPattern.FindStringSubmatch(text); m != nil {
+		switch strings.ToLower(strings.ReplaceAll(m[1], ".", "")) {
+		case "x265", "h265", "hevc":
+			encoding = "x265"
+		case "x264", "h264", "avc":
+			encoding = "x264"
+		case "av1":
+			encoding = "AV1"
+		}
+	}
+	// HEVC releases are almost always MKV, plain ones MP4
+	if format == "" {
+		format = "MP4"
+		if encoding == "x265" {
+			format = "MKV"
+		}
+	}
+	return quality, format, encoding
+}
+
+// GroupDownloads arranges links into a format → encoding → quality tree,
+// best quality first within each format
+func GroupDownloads(links []DownloadLink) []DownloadGroup {
+	var groups []DownloadGroup
+	index := make(map[string]int)
+	for _, link := range links {
+		key := link.Format + "|" + link.Encoding + "|" + link.Quality
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, DownloadGroup{
+				Format:   link.Format,
+				Encoding: link.Encoding,
+				Quality:  link.Quality,
+			})
+		}
+		groups[i].Links = append(groups[i].Links, link)
+	}
+
+	sort.SliceStable(groups, func(a, b int) bool {
+		if groups[a].Format != groups[b].Format {
+			return groups[a].Format > groups[b].Format // MP4 before MKV
+		}
+		if groups[a].Encoding != groups[b].Encoding {
+			return groups[a].Encoding < groups[b].Encoding
+		}
+		return qualityHeight(groups[a].Quality) > qualityHeight(groups[b].Quality)
+	})
+	return groups
+}
+
+// qualityHeight returns 720 for "720p", 0 when unknown
+func qualityHeight(q string) int {
+	n, _ := strconv.Atoi(strings.TrimSuffix(strings.ToLower(q), "p"))
+	return n
+}
@@ -0,0 +1,158 @@
+package winbu
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Index kinds: winbu keeps series and films in separate archives
+const (
+	IndexAnime = "anime"
+	IndexFilm  = "film"
+)
+
+// IndexFilter selects one page of winbu's catalogue. At most one of Letter,
+// Year and Status may be set; with none of them the plain archive is listed.
+type IndexFilter struct {
+	Kind   string // IndexAnime (default) or IndexFilm
+	Letter string // "A".."Z" or "0-9" for the A-Z list
+	Year   int
+	Status string // "ongoing" or "completed"
+	Page   int    // 1-based
+}
+
+// IndexPage is one page of index results
+type IndexPage struct {
+	Items      []Anime
+	Page       int
+	HasNext    bool
+	TotalPages int // 0 when the page shows no numbered pagination
+}
+
+var indexStatuses = map[string]string{
+	"ongoing":   "ongoing",
+	"completed": "completed",
+	"complete":  "completed",
+	"tamat":     "completed",
+}
+
+// ValidateIndexFilter normalises f and rejects unsupported combinations
+func ValidateIndexFilter(f *IndexFilter) error {
+	f.Kind = strings.ToLower(f.Kind)
+	if f.Kind == "" {
+		f.Kind = IndexAnime
+	}
+	if f.Kind != IndexAnime && f.Kind != IndexFilm {
+		return fmt.Errorf("kind must be anime or film")
+	}
+
+	set := 0
+	if f.Letter != "" {
+		f.Letter = strings.ToUpper(f.Letter)
+		if !(len(f.Letter) == 1 && f.Letter[0] >= 'A' && f.Letter[0] <= 'Z') && f.Letter != "0-9" {
+			return fmt.Errorf("letter must be A-Z or 0-9")
+		}
+		set++
+	}
+	if f.Year != 0 {
+		if f.Year < 1950 || f.Year > 2100 {
+			return fmt.Errorf("year out of range")
+		}
+		set++
+	}
+	if f.Status != "" {
+		status, ok := indexStatuses[strings.ToLower(f.Status)]
+		if !ok {
+			return fmt.Errorf("status must be ongoing or completed")
+		}
+		f.Status = status
+		set++
+	}
+	if set > 1 {
+		return fmt.Errorf("letter, year and status cannot be combined")
+	}
+	if f.Page < 1 {
+		f.Page = 1
+	}
+	return nil
+}
+
+// IndexURL builds the archive URL for a validated filter
+func IndexURL(f IndexFilter) string {
+	var base, query string
+	switch {
+	case f.Letter != "":
+		// The A-Z list pages like the archives, with the letter as a query
+		base = fmt.Sprintf("https://winbu.net/daftar-%s/", f.Kind)
+		query = "?show=" + f.Letter
+	case f.Year != 0:
+		base = fmt.Sprintf("https://winbu.net/tahun/%d/", f.Year)
+	case f.Status != "":
+		base = fmt.Sprintf("https://winbu.net/status/%s/", f.Status)
+	default:
+		base = fmt.Sprintf("https://winbu.net/%s/", f.Kind)
+	}
+	if f.Page > 1 {
+		base += "page/" + strconv.Itoa(f.Page) + "/"
+	}
+	return base + query
+}
+
+// ParseIndex parses an archive page (.ml-item cards) or an A-Z list page
+// (plain title links) together with its pagination
+func ParseIndex(doc *goquery.Document, page int) (*IndexPage, error) {
+	result := &IndexPage{Items: []Anime{}, Page: page}
+	seen := make(map[string]bool)
+	add := func(a Anime) {
+		if a.Title == "" || a.Endpoint == "" || seen[a.Endpoint] {
+			return
+		}
+		seen[a.Endpoint] = true
+		if a.Type == "" {
+			a.Type = typeFromEndpoint(a.Endpoint)
+		}
+		result.Items = append(result.Items, a)
+	}
+
+	doc.Find(".ml-item, .a-item").Each(func(i int, s *goquery.Selection) {
+		add(extractAnimeFromItem(s))
+	})
+	if len(result.Items) == 0 {
+		doc.Find(".az-list a, .listabj a, .soralist li a, .daftar li a, .lista a").Each(func(i int, s *goquery.Selection) {
+			href := s.AttrOr("href", "")
+			if !strings.Contains(href, "/anime/") && !strings.Contains(href, "/film/") {
+				return
+			}
+			add(Anime{Title: linkTitle(s), Endpoint: href})
+		})
+	}
+
+	next := fmt.Sprintf("/page/%d/", page+1)
+	result.HasNext = doc.Find("a.next, a.nextpostslink, link[rel='next']").Length() > 0
+	doc.Find(".pagination a, .wp-pagenavi a, a.page-numbers, a.page").Each(func(i int, s *goquery.Selection) {
+		if strings.Contains(s.AttrOr("href", ""), next) {
+			result.HasNext = true
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(s.Text())); err == nil && n > result.TotalPages {
+			result.TotalPages = n
+		}
+	})
+	if result.TotalPages > 0 && result.TotalPages < page {
+		result.TotalPages = page
+	}
+	return result, nil
+}
+
+// typeFromEndpoint tells films from series by their archive path
+func typeFromEndpoint(endpoint string) string {
+	switch {
+	case strings.Contains(endpoint, "/film/"):
+		return "Movie"
+	case strings.Contains(endpoint, "/anime/"), strings.Contains(endpoint, "/series/"):
+		return "Series"
+	}
+	return ""
+}
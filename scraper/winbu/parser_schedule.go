@@ -0,0 +1,191 @@
+package winbu
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ScheduleURL is winbu's "jadwal rilis" page
+const ScheduleURL = "https://winbu.net/jadwal-rilis/"
+
+// WIB is the timezone winbu publishes air times in (UTC+7, no DST)
+var WIB = time.FixedZone("WIB", 7*60*60)
+
+// Weekdays in schedule order, with the labels winbu may use for them
+var scheduleDays = []struct {
+	day    time.Weekday
+	labels []string
+}{
+	{time.Monday, []string{"senin", "monday"}},
+	{time.Tuesday, []string{"selasa", "tuesday"}},
+	{time.Wednesday, []string{"rabu", "wednesday"}},
+	{time.Thursday, []string{"kamis", "thursday"}},
+	{time.Friday, []string{"jumat", "jum'at", "friday"}},
+	{time.Saturday, []string{"sabtu", "saturday"}},
+	{time.Sunday, []string{"minggu", "ahad", "sunday"}},
+}
+
+// ParseWeekday maps "Senin", "monday", ... to a weekday; ok is false for
+// anything else
+func ParseWeekday(text string) (time.Weekday, bool) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	for _, d := range scheduleDays {
+		for _, label := range d.labels {
+			if text == label {
+				return d.day, true
+			}
+		}
+	}
+	return 0, false
+}
+
+var (
+	dayHeadingSelector = "h2, h3, h4, h5, .releases, .day, .hari, .tab-title"
+	airTimePattern     = regexp.MustCompile(`\b([01]?\d|2[0-3])[:.]([0-5]\d)\b`)
+)
+
+// ParseSchedule reads the airing schedule grouped by weekday, Monday first.
+// Days without a heading on the page are omitted.
+func ParseSchedule(doc *goquery.Document) ([]ScheduleDay, error) {
+	byDay := make(map[time.Weekday]*ScheduleDay)
+
+	doc.Find(dayHeadingSelector).Each(func(i int, h *goquery.Selection) {
+		label := strings.TrimSpace(strings.Trim(strings.TrimSpace(h.Text()), ":"))
+		weekday, ok := ParseWeekday(label)
+		if !ok {
+			return
+		}
+
+		// Each day is either its own box or a heading followed by its items
+		var items *goquery.Selection
+		box := h.Closest(".schedulepage, .bixbox, .jadwal, .tab-pane, section")
+		if box.Length() > 0 && box.Find(dayHeadingSelector).FilterFunction(isDayHeading).Length() == 1 {
+			items = box.Find("a[href]")
+		} else {
+			items = followingLinks(h)
+		}
+
+		day, exists := byDay[weekday]
+		if !exists {
+			day = &ScheduleDay{Day: strings.ToLower(weekday.String()), Label: label}
+			byDay[weekday] = day
+		}
+		seen := make(map[string]bool)
+		for _, it := range day.Items {
+			seen[it.Endpoint] = true
+		}
+		items.Each(func(j int, a *goquery.Selection) {
+			if item, ok := parseScheduleItem(a); ok && !seen[item.Endpoint] {
+				seen[item.Endpoint] = true
+				day.Items = append(day.Items, item)
+			}
+		})
+	})
+
+	var days []ScheduleDay
+	for _, d := range scheduleDays {
+		if day, ok := byDay[d.day]; ok {
+			days = append(days, *day)
+		}
+	}
+	return days, nil
+}
+
+// followingLinks collects links in the siblings after a day heading, up to
+// the next day heading
+func followingLinks(h *goquery.Selection) *goquery.Selection {
+	links := h.Slice(0, 0)
+	for sib := h.Next(); sib.Length() > 0; sib = sib.Next() {
+		if sib.Is(dayHeadingSelector) && isDayHeading(0, sib) ||
+			sib.Find(dayHeadingSelector).FilterFunction(isDayHeading).Length() > 0 {
+			break
+		}
+		links = links.AddSelection(sib.Filter("a[href]")).AddSelection(sib.Find("a[href]"))
+	}
+	return links
+}
+
+func isDayHeading(i int, s *goquery.Selection) bool {
+	_, ok := ParseWeekday(strings.Trim(strings.TrimSpace(s.Text()), ":"))
+	return ok
+}
+
+// parseScheduleItem reads one anime link of a day
+func parseScheduleItem(a *goquery.Selection) (ScheduleItem, bool) {
+	href := a.AttrOr("href", "")
+	if href == "" || strings.HasPrefix(href, "#") || strings.Contains(href, "/genre") {
+		return ScheduleItem{}, false
+	}
+
+	// The card around the link carries the time and episode badges
+	card := a.Closest("li, .bs, .bsx, article, .item")
+	if card.Length() == 0 {
+		card = a
+	}
+
+	title := linkTitle(a)
+	if title == "" {
+		return ScheduleItem{}, false
+	}
+
+	item := ScheduleItem{
+		Title:    title,
+		Endpoint: href,
+		Thumb:    card.Find("img").AttrOr("src", card.Find("img").AttrOr("data-src", "")),
+	}
+
+	text := spacedText(card)
+	if _, number := ParseEpisodeNumber(text); number != "" {
+		item.Episode = number
+	}
+
+	// Countdown widgets carry the release moment as a unix timestamp
+	if ts := card.Find("[data-rlsdt], [data-time]").AttrOr("data-rlsdt", card.Find("[data-time]").AttrOr("data-time", "")); ts != "" {
+		if sec, err := strconv.ParseInt(ts, 10, 64); err == nil && sec > 0 {
+			at := time.Unix(sec, 0).In(WIB)
+			item.AirAt = &at
+			item.AirTime = at.Format("15:04")
+		}
+	}
+	if item.AirTime == "" {
+		if m := airTimePattern.FindStringSubmatch(text); m != nil {
+			h, _ := strconv.Atoi(m[1])
+			item.AirTime = strconv.Itoa(100 + h)[1:] + ":" + m[2]
+		}
+	}
+	return item, true
+}
+
+// linkTitle prefers a link's title attribute, then its heading, then text
+func linkTitle(a *goquery.Selection) string {
+	if t := strings.TrimSpace(a.AttrOr("title", "")); t != "" {
+		return t
+	}
+	if t := strings.TrimSpace(a.Find(".tt, h4, h3, h2, .title").First().Text()); t != "" {
+		return strings.Join(strings.Fields(t), " ")
+	}
+	return strings.Join(strings.Fields(a.Text()), " ")
+}
+
+// spacedText is Selection.Text with a space between text nodes, so badges
+// like "<span>at 21:00</span><div>Ep 5</div>" do not run together
+func spacedText(s *goquery.Selection) string {
+	var b strings.Builder
+	var walk func(*goquery.Selection)
+	walk = func(sel *goquery.Selection) {
+		sel.Contents().Each(func(i int, c *goquery.Selection) {
+			if goquery.NodeName(c) == "#text" {
+				b.WriteString(c.Text())
+				b.WriteByte(' ')
+			} else {
+				walk(c)
+			}
+		})
+	}
+	walk(s)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
@@ -114,48 +114,76 @@ func ParseEpisodePage(doc *goquery.Document) (*EpisodePageData, error) {
 		data.PrevEpisodeEndpoint = doc.Find(".fl a").AttrOr("href", "")
 	}
 
-	// Download Links Parsing
-	// Attempt to find download links in common containers
-	// Strategy 1: .download-eps
-	doc.Find(".download-eps a").Each(func(i int, s *goquery.Selection) {
-		link := DownloadLink{
-			Server:  strings.TrimSpace(s.Text()),
-			URL:     s.AttrOr("href", ""),
-			Quality: "Unknown", // Often mixed in text
-		}
+	// Parent series, episode number and the sidebar episode list
+	parseEpisodeContext(doc, data)
 
-		// Try to extract quality from parent or text
-		if strings.Contains(link.Server, "360") {
-			link.Quality = "360p"
-		}
-		if strings.Contains(link.Server, "480") {
-			link.Quality = "480p"
-		}
-		if strings.Contains(link.Server, "720") {
-			link.Quality = "720p"
-		}
-		if strings.Contains(link.Server, "1080") {
-			link.Quality = "1080p"
+	// Download Links, flat and grouped by format/encoding/quality
+	data.DownloadLinks = parseDownloadLinks(doc)
+	data.Downloads = GroupDownloads(data.DownloadLinks)
+
+	return data, nil
+}
+
+// seriesLinkSelectors find the "all episodes" / breadcrumb link back to the
+// anime page, most specific first
+var seriesLinkSelectors = []string{
+	".naveps a",
+	".breadcrumb a",
+	"[itemprop='itemListElement'] a",
+	".det a",
+}
+
+// episodeListSelectors find the episode sidebar of an episode page
+var episodeListSelectors = []string{
+	".tvseason .les-content a",
+	".episodelist li a",
+	".eplister li a",
+	".lstepsiode li a",
+}
+
+func parseEpisodeContext(doc *goquery.Document, data *EpisodePageData) {
+	canonical := pageURL(doc)
+
+	for _, sel := range seriesLinkSelectors {
+		doc.Find(sel).EachWithBreak(func(i int, s *goquery.Selection) bool {
+			href := s.AttrOr("href", "")
+			text := strings.ToLower(s.Text())
+			if strings.Contains(href, "/anime/") || strings.Contains(href, "/film/") || strings.Contains(href, "/series/") ||
+				strings.Contains(text, "all episode") || strings.Contains(text, "semua episode") || strings.Contains(text, "list episode") {
+				data.SeriesEndpoint = href
+				return false
+			}
+			return true
+		})
+		if data.SeriesEndpoint != "" {
+			break
 		}
+	}
+	if data.SeriesEndpoint != "" {
+		data.SeriesSlug = SeriesSlugFromURL(data.SeriesEndpoint)
+	} else {
+		data.SeriesSlug = SeriesSlugFromURL(canonical)
+	}
 
-		if link.URL != "" && !strings.HasPrefix(link.URL, "javascript") {
-			data.DownloadLinks = append(data.DownloadLinks, link)
+	data.EpisodeKind, data.EpisodeNumber = ParseEpisodeNumber(data.Title)
+	if data.EpisodeNumber == "" && canonical != "" {
+		if kind, number := ParseEpisodeNumber(SlugFromURL(canonical)); kind != "" && number != "" {
+			data.EpisodeKind, data.EpisodeNumber = kind, number
 		}
-	})
+	}
 
-	// Strategy 2: #download container
-	if len(data.DownloadLinks) == 0 {
-		doc.Find("#download a").Each(func(i int, s *goquery.Selection) {
-			link := DownloadLink{
-				Server:  strings.TrimSpace(s.Text()),
-				URL:     s.AttrOr("href", ""),
-				Quality: "Unknown",
-			}
-			if link.URL != "" {
-				data.DownloadLinks = append(data.DownloadLinks, link)
+	seen := make(map[string]bool)
+	for _, sel := range episodeListSelectors {
+		doc.Find(sel).Each(func(i int, s *goquery.Selection) {
+			href, exists := s.Attr("href")
+			if !exists || href == "" || seen[href] {
+				return
 			}
+			seen[href] = true
+			data.AllEpisodes = append(data.AllEpisodes, newEpisode(strings.TrimSpace(s.Text()), href, data.SeriesSlug))
 		})
+		if len(data.AllEpisodes) > 0 {
+			break
+		}
 	}
-
-	return data, nil
 }
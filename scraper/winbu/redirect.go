@@ -0,0 +1,105 @@
+package winbu
+
+import (
+	"encoding/base64"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// shortenerHosts are link shorteners and "safelink" interstitials that sit
+// between winbu's download buttons and the actual file host
+var shortenerHosts = []string{
+	"bit.ly", "tinyurl.com", "cutt.ly", "s.id", "shorturl.at", "rebrand.ly",
+	"ouo.io", "ouo.press", "shrinkme.io", "safelinku.com", "sflink.id",
+	"semawur.com", "linkpoi.me", "link.winbu.net", "go.winbu.net",
+}
+
+// IsShortener reports whether host is a known shortener/safelink host
+func IsShortener(host string) bool {
+	host = strings.TrimPrefix(strings.ToLower(host), "www.")
+	for _, h := range shortenerHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return strings.Contains(host, "safelink")
+}
+
+// redirectParams are query parameters safelinks use to carry the target,
+// either as a plain URL or base64 encoded. Generic names such as "r", "q" or
+// "id" are left out: file hosts use them for referrers and searches.
+var redirectParams = []string{"url", "u", "link", "go", "dest", "target"}
+
+// UnwrapRedirect returns the target embedded in a safelink's query string
+// (e.g. "?url=aHR0cHM6Ly9..."), or "" when there is none. Only shortener
+// hosts are unwrapped; other links are taken to be the target already.
+func UnwrapRedirect(u *url.URL) string {
+	if !IsShortener(u.Host) {
+		return ""
+	}
+	q := u.Query()
+	for _, p := range redirectParams {
+		v := strings.TrimSpace(q.Get(p))
+		if v == "" {
+			continue
+		}
+		if target := absoluteURL(v); target != "" {
+			return target
+		}
+		if target := absoluteURL(decodeBase64(v)); target != "" {
+			return target
+		}
+	}
+	return ""
+}
+
+var (
+	metaRefreshPattern = regexp.MustCompile(`(?i)<meta[^>]+http-equiv=["']?refresh["']?[^>]+content=["'][^"']*?url=([^"'>]+)["']`)
+	jsLocationPattern  = regexp.MustCompile(`(?:window\.|document\.|top\.)?location(?:\.href)?\s*=\s*["']([^"']+)["']|location\.(?:replace|assign)\(\s*["']([^"']+)["']\s*\)|window\.open\(\s*["']([^"']+)["']`)
+	base64URLPattern   = regexp.MustCompile(`["'](aHR0c[A-Za-z0-9+/=_-]{10,})["']`) // "aHR0c" is base64 for "http"
+)
+
+// NextHop finds where an interstitial page sends the visitor: a meta refresh,
+// a JavaScript location change, or a base64 encoded URL in the page.
+// Returns "" when the page has no recognisable redirect.
+func NextHop(pageURL *url.URL, body []byte) string {
+	page := string(body)
+
+	if m := metaRefreshPattern.FindStringSubmatch(page); m != nil {
+		return resolveRef(pageURL, html.UnescapeString(strings.Trim(m[1], `'" `)))
+	}
+	for _, m := range jsLocationPattern.FindAllStringSubmatch(page, -1) {
+		for _, target := range m[1:] {
+			if target != "" && !strings.HasPrefix(target, "#") {
+				return resolveRef(pageURL, target)
+			}
+		}
+	}
+	if m := base64URLPattern.FindStringSubmatch(page); m != nil {
+		if target := absoluteURL(decodeBase64(m[1])); target != "" {
+			return target
+		}
+	}
+	return ""
+}
+
+// absoluteURL returns s if it is an absolute http(s) URL, else ""
+func absoluteURL(s string) string {
+	u, err := url.Parse(strings.TrimSpace(s))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return u.String()
+}
+
+// decodeBase64 accepts standard and URL-safe base64, padded or not
+func decodeBase64(s string) string {
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if b, err := enc.DecodeString(s); err == nil {
+			return string(b)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,37 @@
+package winbu
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestUnwrapRedirect(t *testing.T) {
+	tests := []struct {
+		name string
+		link string
+		want string
+	}{
+		{"plain url param", "https://ouo.io/go?url=https%3A%2F%2Fpixeldrain.com%2Fu%2Fabc", "https://pixeldrain.com/u/abc"},
+		{"base64 param", "https://safelinku.com/?link=aHR0cHM6Ly9tZWdhLm56L2ZpbGUveHl6", "https://mega.nz/file/xyz"},
+		{"url-safe base64 without padding", "https://sflink.id/x?go=aHR0cHM6Ly9leGFtcGxlLmNvbS9h", "https://example.com/a"},
+		{"safelink subdomain", "https://dl.mysafelink.net/?target=https://krakenfiles.com/view/1", "https://krakenfiles.com/view/1"},
+		{"shortener without target", "https://bit.ly/3abcDEF", ""},
+		{"param that is not a url", "https://ouo.io/go?url=hello", ""},
+		{"non-http target", "https://ouo.io/go?url=javascript:alert(1)", ""},
+		{"file host with referrer", "https://pixeldrain.com/u/abc?r=https://winbu.net/anime/x/", ""},
+		{"file host with url param", "https://mega.nz/file/xyz?url=https://winbu.net/", ""},
+		{"generic params on shortener", "https://ouo.io/go?r=https://winbu.net/&q=https://a.com/&id=aHR0cHM6Ly9iLmNvbS8", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.link)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := UnwrapRedirect(u); got != tt.want {
+				t.Errorf("UnwrapRedirect(%s) = %q, want %q", tt.link, got, tt.want)
+			}
+		})
+	}
+}
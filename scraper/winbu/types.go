@@ -1,5 +1,7 @@
 package winbu
 
+import "time"
+
 type Anime struct {
 	Title    string
 	Endpoint string
@@ -10,34 +12,73 @@ type Anime struct {
 }
 
 type AnimeDetail struct {
-	Title    string
-	Thumb    string
-	Synopsis string
-	Score    string
-	Genres   []string
-	Episodes []Episode
-	Metadata map[string]string // Status, Type, Released, etc.
+	Title           string
+	AltTitles       []string
+	Thumb           string
+	Synopsis        string
+	Score           float64 // 0 when unrated
+	Genres          []string
+	Status          string // StatusOngoing, StatusCompleted, StatusUpcoming or ""
+	Type            string // "TV", "Movie", "ONA", ...
+	DurationMinutes int    // per episode for series, total for movies
+	Country         string
+	Studio          string
+	Quality         string
+	ReleaseDate     *time.Time
+	Season          string // "winter", "spring", "summer" or "fall"
+	Year            int
+	TotalEpisodes   int
+	Episodes        []Episode
+	Metadata        map[string]string // info rows without a typed field above
 }
 
+// Anime statuses, normalised from winbu's mixed English/Indonesian labels
+const (
+	StatusOngoing   = "ongoing"
+	StatusCompleted = "completed"
+	StatusUpcoming  = "upcoming"
+)
+
 type Episode struct {
-	Title    string
-	Endpoint string
+	Title      string
+	Endpoint   string
+	Number     string // "12", "12.5"; empty for unnumbered specials/movies
+	Kind       string // KindEpisode, KindOVA, KindSpecial or KindMovie
+	SeriesSlug string // slug of the parent anime, e.g. "one-piece"
 }
 
 type EpisodePageData struct {
 	Title               string
 	EpisodeNumber       string
+	EpisodeKind         string
+	SeriesSlug          string
+	SeriesEndpoint      string
 	StreamOptions       []StreamOption
 	NextEpisodeEndpoint string
 	PrevEpisodeEndpoint string
 	AllEpisodes         []Episode
 	DownloadLinks       []DownloadLink
+	Downloads           []DownloadGroup // DownloadLinks grouped by format/encoding/quality
+	Sources             []MediaSource   // filled only when streams are resolved
+	Subtitles           []Subtitle      // filled only when streams are resolved
 }
 
 type DownloadLink struct {
-	Server  string
-	URL     string
-	Quality string
+	Server      string
+	URL         string
+	Quality     string
+	Format      string // container: "MP4", "MKV"
+	Encoding    string // video codec tag: "x265", "x264" or empty
+	ResolvedURL string // final file host URL after shorteners, when resolved
+	Host        string // hostname of ResolvedURL
+}
+
+// DownloadGroup collects the mirrors of one format/encoding/quality combination
+type DownloadGroup struct {
+	Format   string
+	Encoding string
+	Quality  string
+	Links    []DownloadLink
 }
 
 type StreamOption struct {
@@ -62,3 +103,40 @@ type Genre struct {
 	Name     string
 	Endpoint string
 }
+
+// MediaSource is a playable media URL extracted from an embed host
+type MediaSource struct {
+	Server  string // StreamOption server the source was resolved from
+	Host    string // embed hostname, e.g. "pixeldrain.com"
+	URL     string
+	Type    string // "mp4" or "hls"
+	Quality string
+	Label   string
+	Headers map[string]string // headers the host requires for playback (e.g. Referer)
+}
+
+// Subtitle is a soft subtitle track offered by an embed host
+type Subtitle struct {
+	Server   string // StreamOption server the track was found on
+	Language string // ISO 639-1 code when recognisable ("id", "en"), else ""
+	Label    string
+	Format   string // "vtt", "srt" or "ass"
+	URL      string
+	Headers  map[string]string
+}
+
+// ScheduleDay lists the anime airing on one weekday
+type ScheduleDay struct {
+	Day   string // English weekday, lower case: "monday"
+	Label string // heading as shown on winbu, e.g. "Senin"
+	Items []ScheduleItem
+}
+
+type ScheduleItem struct {
+	Title    string
+	Endpoint string
+	Thumb    string
+	Episode  string     // upcoming episode number when shown
+	AirTime  string     // "21:00" in WIB
+	AirAt    *time.Time // exact release moment when the page has a countdown
+}
@@ -0,0 +1,100 @@
+// Command verify_webhooks checks webhook delivery against a local receiver:
+// signed test events arrive, redirects are not followed, and the default
+// client refuses loopback targets.
+//
+//	go run ./scripts/verify_webhooks
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"komiku-scraper/internal/notify"
+	"komiku-scraper/internal/users"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+)
+
+type delivery struct {
+	header http.Header
+	body   []byte
+}
+
+func main() {
+	var (
+		mu       sync.Mutex
+		received []delivery
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hook", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, delivery{r.Header.Clone(), body})
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/redirect", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/hook", http.StatusFound)
+	})
+	mux.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	receiver := httptest.NewServer(mux)
+	defer receiver.Close()
+
+	hook := users.Webhook{ID: 1, URL: receiver.URL + "/hook", Secret: "s3cret", Sink: users.SinkWebhook}
+	failed := false
+	check := func(name string, ok bool, detail any) {
+		if ok {
+			fmt.Printf("PASS %s\n", name)
+			return
+		}
+		fmt.Printf("FAIL %s: %v\n", name, detail)
+		failed = true
+	}
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(received)
+	}
+
+	// The production client must not reach the loopback receiver
+	blocked := notify.NewDispatcher(nil)
+	blocked.AllowPrivate = false
+	blocked.Client = notify.NewClient(false)
+	err := blocked.Test(hook)
+	check("loopback receiver is refused", errors.Is(err, notify.ErrBlockedAddress) && count() == 0, err)
+
+	local := notify.NewDispatcher(nil)
+	local.AllowPrivate = true
+	local.Client = notify.NewClient(true)
+
+	err = local.Test(hook)
+	check("test event is delivered", err == nil && count() == 1, err)
+	if count() == 1 {
+		d := received[0]
+		var e notify.Event
+		jsonErr := json.Unmarshal(d.body, &e)
+		check("body is a release event", jsonErr == nil && e.Type == notify.EventRelease && e.ID == d.header.Get("X-Webhook-Delivery"), jsonErr)
+
+		want := "sha256=" + notify.Sign(hook.Secret, d.header.Get("X-Webhook-Timestamp"), d.body)
+		check("signature matches", d.header.Get("X-Webhook-Signature") == want, d.header.Get("X-Webhook-Signature"))
+	}
+
+	redirect := hook
+	redirect.URL = receiver.URL + "/redirect"
+	err = local.Test(redirect)
+	check("redirects are not followed", err != nil && count() == 1, err)
+
+	failing := hook
+	failing.URL = receiver.URL + "/fail"
+	err = local.Test(failing)
+	check("receiver errors are reported", err != nil, err)
+
+	if failed {
+		os.Exit(1)
+	}
+}